@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"distributed_job_scheduler/pkg/sharding"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+var errLostLeadership = errors.New("lost leadership while writing assignments")
+
+// balancer keeps /scheduler/assignments/ in line with the live pickers
+// registered under /scheduler/pickers/.
+type balancer struct {
+	election     *concurrency.Election
+	handoffGrace time.Duration
+}
+
+// rebalance moves shards in two phases so no shard is ever owned by two
+// pickers at once: first every surviving picker gives up the shards it is
+// about to lose, then (after handoffGrace, long enough for an in-flight scan
+// to notice) the new layout is written. Shards of pickers whose lease has
+// expired are free immediately and need no revocation step.
+func (b *balancer) rebalance(ctx context.Context) error {
+	pickers, err := b.livePickers(ctx)
+	if err != nil {
+		return fmt.Errorf("list pickers: %w", err)
+	}
+	current, err := b.currentAssignments(ctx)
+	if err != nil {
+		return fmt.Errorf("list assignments: %w", err)
+	}
+
+	desired := sharding.Distribute(pickers)
+	if sameLayout(current, desired) {
+		return nil
+	}
+
+	log.Printf("Rebalancing %d shards across %d pickers", sharding.NumShards, len(pickers))
+
+	// Phase 1: revoke shards that are moving away from a live picker.
+	interim := make(map[string][]int, len(current))
+	needsRevoke := false
+	for id, shards := range current {
+		next, alive := desired[id]
+		if !alive {
+			continue // dead picker: dropped in phase 2
+		}
+		kept := sharding.Intersect(shards, next)
+		interim[id] = kept
+		if len(kept) != len(shards) {
+			needsRevoke = true
+		}
+	}
+	if needsRevoke {
+		if err := b.write(ctx, interim, nil); err != nil {
+			return fmt.Errorf("revoke phase: %w", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(b.handoffGrace):
+		}
+	}
+
+	// Phase 2: publish the full layout and drop assignments of dead pickers.
+	var stale []string
+	for id := range current {
+		if _, alive := desired[id]; !alive {
+			stale = append(stale, id)
+		}
+	}
+	if err := b.write(ctx, desired, stale); err != nil {
+		return fmt.Errorf("assign phase: %w", err)
+	}
+
+	for id, shards := range desired {
+		log.Printf("Assigned %d shards to picker %s", len(shards), id)
+	}
+	for _, id := range stale {
+		log.Printf("Removed assignment for departed picker %s", id)
+	}
+	return nil
+}
+
+func (b *balancer) livePickers(ctx context.Context) ([]string, error) {
+	resp, err := etcdClient.Client.Get(ctx, sharding.PickersPrefix, clientv3.WithPrefix(), clientv3.WithKeysOnly())
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		ids = append(ids, sharding.IDFromKey(sharding.PickersPrefix, string(kv.Key)))
+	}
+	return ids, nil
+}
+
+func (b *balancer) currentAssignments(ctx context.Context) (map[string][]int, error) {
+	resp, err := etcdClient.Client.Get(ctx, sharding.AssignmentsPrefix, clientv3.WithPrefix())
+	if err != nil {
+		return nil, err
+	}
+	out := make(map[string][]int, len(resp.Kvs))
+	for _, kv := range resp.Kvs {
+		id := sharding.IDFromKey(sharding.AssignmentsPrefix, string(kv.Key))
+		shards, err := sharding.Decode(kv.Value)
+		if err != nil {
+			// Treat garbage as "owns nothing"; phase 2 overwrites it.
+			log.Printf("Ignoring malformed assignment for %s: %v", id, err)
+			shards = nil
+		}
+		out[id] = shards
+	}
+	return out, nil
+}
+
+// write applies puts and deletes in a single transaction guarded by our
+// election key, so a deposed leader can never overwrite the new leader's layout.
+func (b *balancer) write(ctx context.Context, assignments map[string][]int, deletes []string) error {
+	ops := make([]clientv3.Op, 0, len(assignments)+len(deletes))
+	for id, shards := range assignments {
+		val, err := sharding.Encode(shards)
+		if err != nil {
+			return err
+		}
+		ops = append(ops, clientv3.OpPut(sharding.AssignmentKey(id), val))
+	}
+	for _, id := range deletes {
+		ops = append(ops, clientv3.OpDelete(sharding.AssignmentKey(id)))
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	resp, err := etcdClient.Client.Txn(ctx).
+		If(clientv3.Compare(clientv3.CreateRevision(b.election.Key()), "=", b.election.Rev())).
+		Then(ops...).
+		Commit()
+	if err != nil {
+		return err
+	}
+	if !resp.Succeeded {
+		return errLostLeadership
+	}
+	return nil
+}
+
+func sameLayout(current, desired map[string][]int) bool {
+	if len(current) != len(desired) {
+		return false
+	}
+	for id, shards := range desired {
+		cur, ok := current[id]
+		if !ok || !sharding.Equal(cur, shards) {
+			return false
+		}
+	}
+	return true
+}
@@ -8,6 +8,9 @@ import (
     "time"
 
     "distributed_job_scheduler/pkg/infra"
+    "distributed_job_scheduler/pkg/sharding"
+    "distributed_job_scheduler/pkg/shutdown"
+    clientv3 "go.etcd.io/etcd/client/v3"
     "go.etcd.io/etcd/client/v3/concurrency"
 )
 
@@ -18,10 +21,14 @@ var (
 func main() {
     log.Println("Starting Coordinator Service...")
 
+    ctx, stop := shutdown.NotifyContext()
+    defer stop()
+
     initInfra()
     defer closeInfra()
 
-    runElectionLoop()
+    runElectionLoop(ctx)
+    log.Println("Coordinator Service stopped")
 }
 
 func initInfra() {
@@ -43,7 +50,10 @@ func closeInfra() {
     if etcdClient != nil { etcdClient.Close() }
 }
 
-func runElectionLoop() {
+// runElectionLoop campaigns for leadership and balances shards while leader.
+// On shutdown the leader resigns so a standby takes over without waiting
+// for the session TTL.
+func runElectionLoop(ctx context.Context) {
     // Create a session for concurrency (TTL 5s)
     session, err := concurrency.NewSession(etcdClient.Client, concurrency.WithTTL(5))
     if err != nil {
@@ -55,10 +65,17 @@ func runElectionLoop() {
 
     // Campaign for leadership
     log.Println("Campaigning for leadership...")
-    ctx := context.Background()
     
     // Campaign blocks until elected
-    if err := e.Campaign(ctx, "coordinator-1"); err != nil {
+    coordinatorID, err := os.Hostname()
+    if err != nil {
+        coordinatorID = "coordinator-unknown"
+    }
+    if err := e.Campaign(ctx, coordinatorID); err != nil {
+        if ctx.Err() != nil {
+            log.Println("Shutdown signal received before election")
+            return
+        }
         log.Fatalf("Campaign failed: %v", err)
     }
 
@@ -70,32 +87,85 @@ func runElectionLoop() {
 
     // Watch for connection loss or session expiry
     go func() {
-        <-session.Done()
-        log.Println("Etcd session expired, stepping down...")
-        cancel()
-        os.Exit(1) // simpler to restart pod
+        select {
+        case <-session.Done():
+            log.Println("Etcd session expired, stepping down...")
+            cancel()
+            os.Exit(1) // simpler to restart pod
+        case <-leaderCtx.Done():
+        }
     }()
 
-    balanceShards(leaderCtx)
+    balanceShards(leaderCtx, e)
+
+    log.Println("Shutdown signal received, resigning leadership...")
+    resignCtx, cancelResign := context.WithTimeout(context.Background(), 5*time.Second)
+    defer cancelResign()
+    if err := e.Resign(resignCtx); err != nil {
+        log.Printf("Failed to resign leadership: %v", err)
+    }
 }
 
-func balanceShards(ctx context.Context) {
+func balanceShards(ctx context.Context, e *concurrency.Election) {
+    b := &balancer{election: e, handoffGrace: handoffGrace()}
+
+    // Reconcile once on election so a fresh leader picks up any changes
+    // that happened while there was no coordinator.
+    if err := b.rebalance(ctx); err != nil {
+        log.Printf("Initial rebalance failed: %v", err)
+    }
+
+    watchCh := etcdClient.Client.Watch(ctx, sharding.PickersPrefix, clientv3.WithPrefix())
+
+    // Periodic resync in case a watch event is missed or a write failed
     ticker := time.NewTicker(5 * time.Second)
     defer ticker.Stop()
 
+    // Debounce bursts of joins/expiries (e.g. a rolling deploy) into one rebalance
+    debounce := time.NewTimer(time.Hour)
+    debounce.Stop()
+
     for {
         select {
         case <-ctx.Done():
             return
+        case wresp, ok := <-watchCh:
+            if !ok {
+                log.Println("Picker watch closed, re-establishing...")
+                watchCh = etcdClient.Client.Watch(ctx, sharding.PickersPrefix, clientv3.WithPrefix())
+                continue
+            }
+            if err := wresp.Err(); err != nil {
+                log.Printf("Picker watch error: %v", err)
+                continue
+            }
+            for _, ev := range wresp.Events {
+                pickerID := sharding.IDFromKey(sharding.PickersPrefix, string(ev.Kv.Key))
+                if ev.Type == clientv3.EventTypeDelete {
+                    log.Printf("Picker %s left (lease expired or deregistered)", pickerID)
+                } else if ev.IsCreate() {
+                    log.Printf("Picker %s joined", pickerID)
+                }
+            }
+            debounce.Reset(500 * time.Millisecond)
+        case <-debounce.C:
+            if err := b.rebalance(ctx); err != nil {
+                log.Printf("Rebalance failed: %v", err)
+            }
         case <-ticker.C:
-            // TODO: Watch active pickers and assign shards
-            // For Phase 4.2 core, just log we are alive
-            log.Println("Leader active. Checking cluster state...")
-            
-            // Logic to be implemented in full Phase 4.2
-            // 1. Get Pickers from /scheduler/pickers/ prefix
-            // 2. Distribute 0-1024 shards among them
-            // 3. Write assignments to /scheduler/assignments/
+            if err := b.rebalance(ctx); err != nil {
+                log.Printf("Rebalance failed: %v", err)
+            }
+        }
+    }
+}
+
+func handoffGrace() time.Duration {
+    if v := os.Getenv("HANDOFF_GRACE"); v != "" {
+        if d, err := time.ParseDuration(v); err == nil {
+            return d
         }
+        log.Printf("Invalid HANDOFF_GRACE %q, using default", v)
     }
+    return 2 * time.Second
 }
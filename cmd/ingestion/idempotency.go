@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Keys are remembered for a day; a client retrying later than that gets a new job.
+const idempotencyTTL = 24 * time.Hour
+
+const maxIdempotencyKeyLen = 255
+
+// idempotencyClaim is held by the request that won the LWT on a key.
+// A nil claim (no Idempotency-Key header) makes every method a no-op.
+type idempotencyClaim struct {
+	key       string
+	jobID     string
+	expiresAt time.Time
+}
+
+// idempotencyRecord is what a losing request finds already stored.
+type idempotencyRecord struct {
+	JobID       string
+	RequestHash string
+	StatusCode  int // 0 while the original request is still in flight
+}
+
+// requestFingerprint hashes the decoded request rather than the raw body so
+// whitespace or key order differences in a retried body still match.
+func requestFingerprint(req JobRequest) string {
+	b, _ := json.Marshal(req)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// claimIdempotencyKey inserts the key with IF NOT EXISTS. Exactly one of the
+// returned claim/record is non-nil on success: the claim if we won and should
+// create jobID, the record if another request already owns the key.
+func claimIdempotencyKey(userID, key, fingerprint, jobID string) (*idempotencyClaim, *idempotencyRecord, error) {
+	// Scope keys per user so two clients can't collide on the same key
+	scoped := userID + ":" + key
+	now := time.Now()
+
+	existing := map[string]interface{}{}
+	query := `INSERT INTO idempotency_lookup (idempotency_key, job_id, request_hash, status_code, created_at) VALUES (?, ?, ?, ?, ?) IF NOT EXISTS USING TTL ?`
+	applied, err := scyllaClient.Session.Query(query, scoped, jobID, fingerprint, 0, now, int(idempotencyTTL.Seconds())).MapScanCAS(existing)
+	if err != nil {
+		return nil, nil, err
+	}
+	if applied {
+		return &idempotencyClaim{key: scoped, jobID: jobID, expiresAt: now.Add(idempotencyTTL)}, nil, nil
+	}
+
+	rec := &idempotencyRecord{}
+	if id, ok := existing["job_id"].(gocql.UUID); ok {
+		rec.JobID = id.String()
+	}
+	rec.RequestHash, _ = existing["request_hash"].(string)
+	rec.StatusCode, _ = existing["status_code"].(int)
+	// The job is stored before the result is recorded; if the original
+	// request died in between, its job still answers the retry
+	if rec.StatusCode == 0 && rec.JobID != "" {
+		var jobID gocql.UUID
+		err := scyllaClient.Session.Query(`SELECT job_id FROM jobs WHERE job_id = ?`, rec.JobID).Scan(&jobID)
+		if err == nil {
+			rec.StatusCode = http.StatusCreated
+		} else if err != gocql.ErrNotFound {
+			return nil, nil, err
+		}
+	}
+	return nil, rec, nil
+}
+
+// complete records the response code so later retries replay it. Like the
+// claim and release it is an LWT: plain writes to a row otherwise written
+// through Paxos can be shadowed by its timestamps under clock skew.
+func (c *idempotencyClaim) complete(statusCode int) {
+	if c == nil {
+		return
+	}
+	// Keep the same absolute expiry as the rest of the row
+	ttl := int(time.Until(c.expiresAt).Seconds())
+	if ttl < 1 {
+		ttl = 1
+	}
+	query := `UPDATE idempotency_lookup USING TTL ? SET status_code = ? WHERE idempotency_key = ? IF job_id = ?`
+	if _, err := scyllaClient.Session.Query(query, ttl, statusCode, c.key, c.jobID).MapScanCAS(map[string]interface{}{}); err != nil {
+		log.Printf("Failed to record idempotency result for %s: %v", c.key, err)
+	}
+}
+
+// release drops the claim when the request failed before anything durable
+// was written, so the client can safely retry with the same key.
+func (c *idempotencyClaim) release() {
+	if c == nil {
+		return
+	}
+	query := `DELETE FROM idempotency_lookup WHERE idempotency_key = ? IF job_id = ?`
+	if _, err := scyllaClient.Session.Query(query, c.key, c.jobID).MapScanCAS(map[string]interface{}{}); err != nil {
+		log.Printf("Failed to release idempotency key %s: %v", c.key, err)
+	}
+}
+
+// writeIdempotentReplay answers a retried request from the stored record.
+// It returns the HTTP status written, for metrics.
+func writeIdempotentReplay(w http.ResponseWriter, rec *idempotencyRecord, fingerprint string) int {
+	if rec.RequestHash != fingerprint {
+		http.Error(w, "Idempotency-Key was already used with a different request body", http.StatusConflict)
+		return http.StatusConflict
+	}
+	if rec.StatusCode == 0 {
+		http.Error(w, "A request with this Idempotency-Key is still in progress", http.StatusConflict)
+		return http.StatusConflict
+	}
+
+	resp := JobResponse{JobID: rec.JobID, Status: "Submitted", Message: "Job submitted successfully"}
+	if rec.StatusCode >= http.StatusInternalServerError {
+		resp.Status = "Error"
+		resp.Message = "Original request failed after the job was stored"
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Idempotent-Replayed", "true")
+	w.WriteHeader(rec.StatusCode)
+	json.NewEncoder(w).Encode(resp)
+	return rec.StatusCode
+}
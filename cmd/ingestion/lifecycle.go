@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+
+	"distributed_job_scheduler/pkg/infra"
+	"distributed_job_scheduler/pkg/observability"
+)
+
+// jobTransition describes one of the POST /job/{id}/<action> endpoints.
+type jobTransition struct {
+	action  string   // URL suffix, e.g. "cancel"
+	to      string   // status written to jobs/user_jobs
+	from    []string // statuses the job may currently be in
+	message string
+}
+
+var (
+	cancelTransition = jobTransition{
+		action:  "cancel",
+		to:      "CANCELLED",
+		from:    []string{"PENDING", "RETRYING", "PAUSED"},
+		message: "Job cancelled",
+	}
+	pauseTransition = jobTransition{
+		action:  "pause",
+		to:      "PAUSED",
+		from:    []string{"PENDING", "RETRYING"},
+		message: "Job paused",
+	}
+	resumeTransition = jobTransition{
+		action:  "resume",
+		to:      "PENDING",
+		from:    []string{"PAUSED"},
+		message: "Job resumed",
+	}
+)
+
+// jobTransitionHandler moves a job between lifecycle states. The status change
+// itself is a lightweight transaction so it can't race the worker or another
+// request; job_queue is then updated to match:
+//   - cancel/pause remove the pending job_queue row so the picker never sees it
+//   - resume restores the row at the job's stored next_fire_at
+//   - cancel also notifies workers so a run already in SQS or executing is stopped
+func jobTransitionHandler(t jobTransition) http.HandlerFunc {
+	path := "/job/{id}/" + t.action
+	return func(w http.ResponseWriter, r *http.Request) {
+		status := "200"
+		start := time.Now()
+		defer func() {
+			observability.HttpRequestDuration.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+			observability.HttpRequestsTotal.WithLabelValues(r.Method, path, status).Inc()
+		}()
+
+		jobID := r.PathValue("id")
+		if _, err := gocql.ParseUUID(jobID); err != nil {
+			status = "400"
+			http.Error(w, "Invalid job id", http.StatusBadRequest)
+			return
+		}
+
+		var ownerID string
+		var createdAt, nextFireAt time.Time
+		var shardID int
+		query := `SELECT user_id, created_at, shard_id, next_fire_at FROM jobs WHERE job_id = ?`
+		err := scyllaClient.Session.Query(query, jobID).Scan(&ownerID, &createdAt, &shardID, &nextFireAt)
+		if err == gocql.ErrNotFound {
+			status = "404"
+			http.Error(w, "Job not found", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("Scylla query failed: %v", err)
+			status = "500"
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+
+		if ownerID != "" && r.Header.Get("X-User-ID") != ownerID {
+			status = "403"
+			http.Error(w, "Job belongs to another user", http.StatusForbidden)
+			return
+		}
+
+		current, applied, err := casJobStatus(jobID, t.to, t.from)
+		if err != nil {
+			log.Printf("Failed to %s job %s: %v", t.action, jobID, err)
+			status = "500"
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		if !applied {
+			status = "409"
+			http.Error(w, "Cannot "+t.action+" job in status "+current, http.StatusConflict)
+			return
+		}
+
+		switch t.to {
+		case "CANCELLED", "PAUSED":
+			delQuery := `DELETE FROM job_queue WHERE shard_id = ? AND next_fire_at = ? AND job_id = ?`
+			if err := scyllaClient.Session.Query(delQuery, shardID, nextFireAt, jobID).Exec(); err != nil {
+				// The picker also re-checks jobs.status before dispatching
+				log.Printf("Failed to remove job %s from queue: %v", jobID, err)
+			}
+		case "PENDING":
+			insQuery := `INSERT INTO job_queue (shard_id, next_fire_at, job_id, status) VALUES (?, ?, ?, ?)`
+			if err := scyllaClient.Session.Query(insQuery, shardID, nextFireAt, jobID, "PENDING").Exec(); err != nil {
+				log.Printf("Failed to restore job %s to queue: %v", jobID, err)
+				status = "500"
+				http.Error(w, "Internal Storage Error", http.StatusInternalServerError)
+				return
+			}
+		}
+
+		if t.to == "CANCELLED" {
+			if err := redisClient.Client.Publish(context.TODO(), infra.JobCancellationChannel, jobID).Err(); err != nil {
+				// Workers also poll jobs.status while a run executes
+				log.Printf("Failed to broadcast cancellation of job %s: %v", jobID, err)
+			}
+		}
+
+		if ownerID != "" {
+			userQuery := `UPDATE user_jobs SET status = ? WHERE user_id = ? AND created_at = ? AND job_id = ?`
+			if err := scyllaClient.Session.Query(userQuery, t.to, ownerID, createdAt, jobID).Exec(); err != nil {
+				log.Printf("Failed to update user_jobs for job %s (non-fatal): %v", jobID, err)
+			}
+		}
+
+		log.Printf("Job %s: %s -> %s", jobID, current, t.to)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(JobResponse{
+			JobID:   jobID,
+			Status:  t.to,
+			Message: t.message,
+		})
+	}
+}
+
+// casJobStatus sets jobs.status to `to` only if it is currently one of `from`.
+// It returns the status observed before the update.
+func casJobStatus(jobID, to string, from []string) (string, bool, error) {
+	placeholders := strings.TrimSuffix(strings.Repeat("?, ", len(from)), ", ")
+	query := `UPDATE jobs SET status = ?, updated_at = ? WHERE job_id = ? IF status IN (` + placeholders + `)`
+
+	args := []interface{}{to, time.Now(), jobID}
+	for _, s := range from {
+		args = append(args, s)
+	}
+
+	previous := map[string]interface{}{}
+	applied, err := scyllaClient.Session.Query(query, args...).MapScanCAS(previous)
+	if err != nil {
+		return "", false, err
+	}
+	current, _ := previous["status"].(string)
+	return current, applied, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// jobListQuery is a parsed GET /jobs request.
+type jobListQuery struct {
+	userID        string
+	status        string
+	projectID     string
+	createdAfter  time.Time // inclusive
+	createdBefore time.Time // exclusive
+	ascending     bool
+	limit         int
+	pageState     []byte
+}
+
+// parseJobListQuery reads limit, cursor, status, project_id, created_after,
+// created_before and order from the query string.
+func parseJobListQuery(userID string, r *http.Request) (jobListQuery, *fieldError) {
+	q := r.URL.Query()
+	lq := jobListQuery{
+		userID:    userID,
+		status:    strings.ToUpper(q.Get("status")),
+		projectID: q.Get("project_id"),
+		limit:     defaultListLimit,
+	}
+
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxListLimit {
+			return lq, &fieldError{"invalid_limit", "limit", "limit must be between 1 and " + strconv.Itoa(maxListLimit)}
+		}
+		lq.limit = n
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  *time.Time
+	}{{"created_after", &lq.createdAfter}, {"created_before", &lq.createdBefore}} {
+		if v := q.Get(p.name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				return lq, &fieldError{"invalid_" + p.name, p.name, p.name + " must be an RFC3339 timestamp"}
+			}
+			*p.dst = t
+		}
+	}
+
+	switch q.Get("order") {
+	case "", "desc":
+	case "asc":
+		lq.ascending = true
+	default:
+		return lq, &fieldError{"invalid_order", "order", "order must be asc or desc"}
+	}
+
+	if v := q.Get("cursor"); v != "" {
+		state, ok := lq.decodeCursor(v)
+		if !ok {
+			return lq, &fieldError{"invalid_cursor", "cursor", "cursor is malformed or was issued for different filters"}
+		}
+		lq.pageState = state
+	}
+	return lq, nil
+}
+
+// statement builds the user_jobs query. The created-at range and order use
+// the clustering key; status and project are filtered by Scylla within the
+// user's partition, so a page may hold fewer than limit rows.
+func (lq jobListQuery) statement() (string, []interface{}) {
+	var b strings.Builder
+	b.WriteString(`SELECT job_id, project_id, status, next_fire_at, created_at FROM user_jobs WHERE user_id = ?`)
+	args := []interface{}{lq.userID}
+
+	if !lq.createdAfter.IsZero() {
+		b.WriteString(` AND created_at >= ?`)
+		args = append(args, lq.createdAfter)
+	}
+	if !lq.createdBefore.IsZero() {
+		b.WriteString(` AND created_at < ?`)
+		args = append(args, lq.createdBefore)
+	}
+	if lq.status != "" {
+		b.WriteString(` AND status = ?`)
+		args = append(args, lq.status)
+	}
+	if lq.projectID != "" {
+		b.WriteString(` AND project_id = ?`)
+		args = append(args, lq.projectID)
+	}
+	if lq.ascending {
+		b.WriteString(` ORDER BY created_at ASC`)
+	}
+	if lq.status != "" || lq.projectID != "" {
+		b.WriteString(` ALLOW FILTERING`)
+	}
+	return b.String(), args
+}
+
+// Cursors wrap Scylla's paging state with a digest of the filters it was
+// issued for; a paging state replayed against a different query would
+// silently return the wrong rows.
+func (lq jobListQuery) filterDigest() []byte {
+	return cursorScope(lq.userID, lq.status, lq.projectID,
+		lq.createdAfter.Format(time.RFC3339Nano), lq.createdBefore.Format(time.RFC3339Nano),
+		strconv.FormatBool(lq.ascending))
+}
+
+func (lq jobListQuery) encodeCursor(pageState []byte) string {
+	return encodeCursor(lq.filterDigest(), pageState)
+}
+
+func (lq jobListQuery) decodeCursor(cursor string) ([]byte, bool) {
+	return decodeCursor(lq.filterDigest(), cursor)
+}
+
+// cursorScope digests the parameters that identify a paged query.
+func cursorScope(params ...string) []byte {
+	sum := sha256.Sum256([]byte(strings.Join(params, "\x00")))
+	return sum[:8]
+}
+
+// encodeCursor returns "" once there are no more pages.
+func encodeCursor(scope, pageState []byte) string {
+	if len(pageState) == 0 {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(append(append([]byte{}, scope...), pageState...))
+}
+
+func decodeCursor(scope []byte, cursor string) ([]byte, bool) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(raw) <= len(scope) || !bytes.Equal(raw[:len(scope)], scope) {
+		return nil, false
+	}
+	return raw[len(scope):], true
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/redis/go-redis/v9"
+
+	"distributed_job_scheduler/pkg/infra"
+	"distributed_job_scheduler/pkg/observability"
+)
+
+const (
+	logReadBatch = 500
+	// How long a follower waits for new lines before sending a keep-alive
+	logFollowBlock = 10 * time.Second
+	// Default for LOG_FOLLOWERS_MAX
+	defaultMaxLogFollowers = 100
+)
+
+// Followers hold a Redis connection in XREAD BLOCK for as long as they are
+// connected, so they get their own pool, sized to the follower cap, rather
+// than starving the shared client used by every other request.
+var (
+	logFollowClient *infra.RedisClient
+	logFollowSlots  chan struct{}
+)
+
+// initLogFollowers connects the followers' Redis client. Above
+// LOG_FOLLOWERS_MAX concurrent followers, ?follow=true is answered with 503.
+func initLogFollowers(redisAddr string) {
+	limit := defaultMaxLogFollowers
+	if v := os.Getenv("LOG_FOLLOWERS_MAX"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			limit = n
+		} else {
+			log.Printf("Invalid LOG_FOLLOWERS_MAX %q, using %d", v, limit)
+		}
+	}
+	var err error
+	logFollowClient, err = infra.NewRedisClientWithPool(redisAddr, "", 0, limit)
+	if err != nil {
+		log.Fatalf("Failed to connect to Redis for log followers: %v", err)
+	}
+	logFollowSlots = make(chan struct{}, limit)
+}
+
+// serverCtx is cancelled on SIGTERM so followed log streams end and don't
+// hold up the drain of the HTTP server.
+var serverCtx = context.Background()
+
+var streamEntryID = regexp.MustCompile(`^\d+-\d+$`)
+
+// runLogsHandler serves GET /job/{id}/runs/{run_id}/logs as Server-Sent
+// Events, read from the run's live log stream (see infra.RunLogStream):
+//
+//	id: 1760650000000-0
+//	event: stdout
+//	data: {"ts": "2026-10-16T21:00:00.123Z", "line": "..."}
+//
+// Lines arrive as "stdout" and "stderr" events, ?stream= selects one of them.
+// "start" and "interrupted" mark attempts and "end" carries the final status.
+// Without ?follow=true the response ends with the lines logged so far;
+// with it, it stays open until "end". A reconnecting client resumes after
+// Last-Event-ID.
+func runLogsHandler(w http.ResponseWriter, r *http.Request) {
+	const path = "/job/{id}/runs/{run_id}/logs"
+	status := "200"
+	start := time.Now()
+	defer func() {
+		observability.HttpRequestDuration.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+		observability.HttpRequestsTotal.WithLabelValues(r.Method, path, status).Inc()
+	}()
+
+	jobID, runID := r.PathValue("id"), r.PathValue("run_id")
+	if s := authorizeJobRead(w, r, jobID); s != "" {
+		status = s
+		return
+	}
+	if _, err := gocql.ParseUUID(runID); err != nil {
+		status = "400"
+		http.Error(w, "Invalid run id", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	follow := false
+	if v := query.Get("follow"); v != "" {
+		var err error
+		if follow, err = strconv.ParseBool(v); err != nil {
+			status = "400"
+			writeFieldError(w, &fieldError{"invalid_follow", "follow", "follow must be true or false"})
+			return
+		}
+	}
+	only := query.Get("stream")
+	if only != "" && only != "stdout" && only != "stderr" {
+		status = "400"
+		writeFieldError(w, &fieldError{"invalid_stream", "stream", "stream must be stdout or stderr"})
+		return
+	}
+	lastID := "0"
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		if !streamEntryID.MatchString(v) {
+			status = "400"
+			http.Error(w, "Invalid Last-Event-ID", http.StatusBadRequest)
+			return
+		}
+		lastID = v
+	}
+
+	rdb := redisClient.Client
+	if follow {
+		select {
+		case logFollowSlots <- struct{}{}:
+			defer func() { <-logFollowSlots }()
+		default:
+			status = "503"
+			w.Header().Set("Retry-After", strconv.Itoa(int(logFollowBlock.Seconds())))
+			http.Error(w, "Too many log followers, try again later", http.StatusServiceUnavailable)
+			return
+		}
+		rdb = logFollowClient.Client
+	}
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	stopOnShutdown := context.AfterFunc(serverCtx, cancel)
+	defer stopOnShutdown()
+
+	key := infra.RunLogStream(jobID, runID)
+	n, err := rdb.Exists(ctx, key).Result()
+	if err != nil {
+		log.Printf("Redis query failed: %v", err)
+		status = "500"
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if n == 0 {
+		status = "404"
+		http.Error(w, "No live log for this run: it hasn't started, or finished more than a day ago (see /output)", http.StatusNotFound)
+		return
+	}
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if flusher != nil {
+		flusher.Flush()
+	}
+
+	block := time.Duration(-1) // no BLOCK: return what's there
+	if follow {
+		block = logFollowBlock
+	}
+	for ctx.Err() == nil {
+		streams, err := rdb.XRead(ctx, &redis.XReadArgs{
+			Streams: []string{key, lastID},
+			Count:   logReadBatch,
+			Block:   block,
+		}).Result()
+		if err == redis.Nil {
+			if !follow || !runLogOpen(ctx, w, rdb, jobID, runID, key) {
+				return
+			}
+			fmt.Fprint(w, ": keep-alive\n\n")
+			if flusher != nil {
+				flusher.Flush()
+			}
+			continue
+		}
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Reading live log %s failed: %v", key, err)
+			}
+			return
+		}
+
+		for _, msg := range streams[0].Messages {
+			lastID = msg.ID
+			event, _ := msg.Values["type"].(string)
+			if (event == "stdout" || event == "stderr") && only != "" && event != only {
+				continue
+			}
+			delete(msg.Values, "type")
+			writeLogEvent(w, msg.ID, event, msg.Values)
+			if event == "end" {
+				if flusher != nil {
+					flusher.Flush()
+				}
+				return
+			}
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+}
+
+func writeLogEvent(w http.ResponseWriter, id, event string, data map[string]interface{}) {
+	body, _ := json.Marshal(data)
+	if id != "" {
+		fmt.Fprintf(w, "id: %s\n", id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, body)
+}
+
+// runLogOpen is checked while a follower waits. A worker that crashed never
+// ends its stream; if the run was recorded since (by the worker that re-ran
+// it) or the stream expired, the follower gets "end" or is disconnected.
+func runLogOpen(ctx context.Context, w http.ResponseWriter, rdb *redis.Client, jobID, runID, key string) bool {
+	if n, err := rdb.Exists(ctx, key).Result(); err == nil && n == 0 {
+		return false
+	}
+	var status string
+	err := scyllaClient.Session.Query(`SELECT status FROM job_runs WHERE job_id = ? AND run_id = ?`, jobID, runID).
+		WithContext(ctx).Scan(&status)
+	if err == nil && status != "INTERRUPTED" {
+		writeLogEvent(w, "", "end", map[string]interface{}{"ts": time.Now().Format(time.RFC3339Nano), "status": status})
+		return false
+	}
+	return true
+}
+
+// liveRuns returns the job's executing runs, newest first, as RUNNING
+// records. Workers list them in Redis until the run is written to job_runs.
+func liveRuns(ctx context.Context, jobID string) []runRecord {
+	entries, err := redisClient.Client.HGetAll(ctx, infra.LiveRunsKey(jobID)).Result()
+	if err != nil {
+		log.Printf("Failed to list live runs of job %s: %v", jobID, err)
+		return nil
+	}
+	runs := make([]runRecord, 0, len(entries))
+	for runID, raw := range entries {
+		var live infra.LiveRun
+		if err := json.Unmarshal([]byte(raw), &live); err != nil {
+			continue
+		}
+		startedAt := live.StartedAt
+		runs = append(runs, runRecord{
+			RunID:     runID,
+			Status:    "RUNNING",
+			Attempt:   live.Attempt,
+			WorkerID:  live.WorkerID,
+			StartedAt: &startedAt,
+		})
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].StartedAt.After(*runs[j].StartedAt) })
+	return runs
+}
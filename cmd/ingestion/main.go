@@ -7,6 +7,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -16,17 +17,29 @@ import (
 	"github.com/gocql/gocql"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 
+	"distributed_job_scheduler/pkg/executor"
 	"distributed_job_scheduler/pkg/infra"
 	"distributed_job_scheduler/pkg/observability"
+	"distributed_job_scheduler/pkg/outbox"
+	"distributed_job_scheduler/pkg/schedule"
+	"distributed_job_scheduler/pkg/shutdown"
 )
 
+// How long a submission waits on Kafka before leaving the event to the relay.
+const inlinePublishTimeout = 5 * time.Second
+
 // JobRequest represents the client submission
 type JobRequest struct {
-    ProjectID    string `json:"project_id"`
-    Payload      string `json:"payload"`
-    CronSchedule string `json:"cron_schedule"`
-    NextFireAt   string `json:"next_fire_at"` // ISO8601
-    MaxRetries   int    `json:"max_retries"`
+    ProjectID      string `json:"project_id"`
+    JobType        string `json:"job_type"` // executor to run payload with; inferred from a "cmd:"-style prefix if empty
+    Payload        string `json:"payload"`
+    CronSchedule   string `json:"cron_schedule"`
+    NextFireAt     string `json:"next_fire_at"` // ISO8601
+    MaxRetries     int    `json:"max_retries"`
+    TimeoutSeconds int    `json:"timeout_seconds"` // per-run deadline; 0 = worker default
+    MisfirePolicy  string `json:"misfire_policy"`  // fire_once (default), fire_all or skip
+    MaxCatchup     int    `json:"max_catchup"`     // fire_all replay limit; 0 = unlimited
+    Timezone       string `json:"timezone"`        // IANA zone for cron_schedule, e.g. Europe/Berlin; default UTC
 }
 
 // JobResponse represents the success response
@@ -49,28 +62,48 @@ func main() {
     // 1. Initialize Infrastructure
     initInfra()
     defer closeInfra()
+    executor.CommandProjects = executor.ParseProjects(os.Getenv("CMD_ALLOWED_PROJECTS"))
+    if len(executor.CommandProjects) == 0 {
+        log.Println("CMD_ALLOWED_PROJECTS is not set: cmd jobs are refused for every project (\"*\" allows all)")
+    }
 
     // 2. Setup Router
     http.HandleFunc("/health", healthHandler)
     http.HandleFunc("/submit", submitHandler)
     http.HandleFunc("/job", getJobHandler)
     http.HandleFunc("/jobs", getJobsHandler)
+    http.HandleFunc("POST /job/{id}/cancel", jobTransitionHandler(cancelTransition))
+    http.HandleFunc("POST /job/{id}/pause", jobTransitionHandler(pauseTransition))
+    http.HandleFunc("POST /job/{id}/resume", jobTransitionHandler(resumeTransition))
+    http.HandleFunc("GET /job/{id}/upcoming", jobUpcomingHandler)
+    http.HandleFunc("GET /job/{id}/runs", listRunsHandler)
+    http.HandleFunc("GET /job/{id}/runs/{run_id}", getRunHandler)
+    http.HandleFunc("GET /job/{id}/runs/{run_id}/output", runOutputHandler)
+    http.HandleFunc("GET /job/{id}/runs/{run_id}/logs", runLogsHandler)
+    http.HandleFunc("GET /schedule/preview", schedulePreviewHandler)
+
+    ctx, stop := shutdown.NotifyContext()
+    defer stop()
+    grace := shutdown.GracePeriod()
+    serverCtx = ctx
 
     // 3. Metrics Endpoint (separate port)
-    go func() {
-        metricshttp := http.NewServeMux()
-        metricshttp.Handle("/metrics", promhttp.Handler())
-        log.Println("Metrics endpoint listening on :8081")
-        if err := http.ListenAndServe(":8081", metricshttp); err != nil {
-            log.Fatalf("Metrics server failed: %v", err)
-        }
-    }()
+    metricshttp := http.NewServeMux()
+    metricshttp.Handle("/metrics", promhttp.Handler())
+    log.Println("Metrics endpoint listening on :8081")
+    metricsDone := shutdown.ListenAndServe(ctx, &http.Server{Addr: ":8081", Handler: metricshttp}, grace)
 
     // 4. Start Server
     log.Println("Ingestion Service listening on :8080")
-    if err := http.ListenAndServe(":8080", nil); err != nil {
-        log.Fatalf("Server failed: %v", err)
-    }
+    apiDone := shutdown.ListenAndServe(ctx, &http.Server{Addr: ":8080"}, grace)
+
+    // 5. On SIGTERM: stop accepting requests, let in-flight submissions finish,
+    // then closeInfra flushes any buffered Kafka messages.
+    <-ctx.Done()
+    log.Printf("Shutdown signal received, draining HTTP servers (grace %v)...", grace)
+    <-apiDone
+    <-metricsDone
+    log.Println("Ingestion Service stopped")
 }
 
 // ... (initInfra, etc.)
@@ -92,9 +125,9 @@ func getJobHandler(w http.ResponseWriter, r *http.Request) {
     var nextFireAt time.Time
     var createdAt time.Time
 
-    query := `SELECT project_id, payload, cron_schedule, next_fire_at, status, created_at FROM jobs WHERE job_id = ?`
+    query := `SELECT project_id, job_type, payload, cron_schedule, next_fire_at, status, created_at FROM jobs WHERE job_id = ?`
     err := scyllaClient.Session.Query(query, jobID).Scan(
-        &job.ProjectID, &job.Payload, &job.CronSchedule, &nextFireAt, &status, &createdAt)
+        &job.ProjectID, &job.JobType, &job.Payload, &job.CronSchedule, &nextFireAt, &status, &createdAt)
 
     if err != nil {
         if strings.Contains(err.Error(), "not found") {
@@ -110,6 +143,7 @@ func getJobHandler(w http.ResponseWriter, r *http.Request) {
     resp := map[string]interface{}{
         "job_id": jobID,
         "project_id": job.ProjectID,
+        "job_type": job.JobType,
         "payload": job.Payload,
         "status": status,
         "next_fire_at": nextFireAt,
@@ -144,18 +178,28 @@ func getJobsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Query user_jobs table for efficient lookups by User ID
-	query := `SELECT job_id, status, next_fire_at, created_at FROM user_jobs WHERE user_id = ?`
-	iter := scyllaClient.Session.Query(query, userID).Iter()
-	
-	var jobs []map[string]string
+	lq, fe := parseJobListQuery(userID, r)
+	if fe != nil {
+		status = "400"
+		writeFieldError(w, fe)
+		return
+	}
+
+	// Query user_jobs table for efficient lookups by User ID, one page at a time
+	query, args := lq.statement()
+	iter := scyllaClient.Session.Query(query, args...).PageSize(lq.limit).PageState(lq.pageState).Iter()
+	nextPage := iter.PageState()
+
+	jobs := []map[string]string{}
 	var id gocql.UUID
-	var statusDB string
+	var projectID, statusDB string
 	var nextFireAt, createdAt *time.Time
 
-	for iter.Scan(&id, &statusDB, &nextFireAt, &createdAt) {
+	// Only the rows of this page: stop before the iterator fetches the next one
+	for n := iter.NumRows(); n > 0 && iter.Scan(&id, &projectID, &statusDB, &nextFireAt, &createdAt); n-- {
 		job := map[string]string{
 			"job_id": id.String(),
+			"project_id": projectID,
 			"status": statusDB,
 		}
 		
@@ -183,8 +227,12 @@ func getJobsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	resp := map[string]interface{}{"jobs": jobs}
+	if cursor := lq.encodeCursor(nextPage); cursor != "" {
+		resp["next_cursor"] = cursor
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(jobs)
+	json.NewEncoder(w).Encode(resp)
 }
 
 func initInfra() {
@@ -210,6 +258,7 @@ func initInfra() {
 	if err != nil {
 		log.Fatalf("Failed to connect to Redis: %v", err)
 	}
+	initLogFollowers(redisAddr)
 	log.Println("Connected to Redis")
 
 	// Kafka
@@ -246,6 +295,9 @@ func closeInfra() {
 	if redisClient != nil {
 		redisClient.Close()
 	}
+	if logFollowClient != nil {
+		logFollowClient.Close()
+	}
 	if kafkaProducer != nil {
 		kafkaProducer.Close()
 	}
@@ -278,16 +330,72 @@ func submitHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	jobID := uuid.New().String()
-
 	// Consistent timestamp for both tables
 	now := time.Now()
+
+	nextFireAt, fieldErr := validateSchedule(req, now)
+	if fieldErr != nil {
+		status = "400"
+		writeFieldError(w, fieldErr)
+		return
+	}
+	jobType, payload, fieldErr := validateJobType(req)
+	if fieldErr != nil {
+		status = "400"
+		writeFieldError(w, fieldErr)
+		return
+	}
+	if fieldErr := authorizeJobType(req, jobType); fieldErr != nil {
+		status = "403"
+		writeFieldErrorStatus(w, http.StatusForbidden, fieldErr)
+		return
+	}
+	misfirePolicy := req.MisfirePolicy
+	if misfirePolicy == "" {
+		misfirePolicy = schedule.DefaultMisfirePolicy
+	}
+	timezone := req.Timezone
+	if timezone == "" {
+		timezone = schedule.DefaultTimezone
+	}
+
+	jobID := uuid.New().String()
 	shardID := int(now.UnixNano()) % 1024 // Simple sharding for now
 	userID := r.Header.Get("X-User-ID")
+	var err error
+
+	// Idempotency: claim the key before creating anything
+	var claim *idempotencyClaim
+	if idemKey := r.Header.Get("Idempotency-Key"); idemKey != "" {
+		if len(idemKey) > maxIdempotencyKeyLen {
+			status = "400"
+			http.Error(w, "Idempotency-Key too long", http.StatusBadRequest)
+			return
+		}
+		// Keys are scoped per user; without one, all anonymous clients would share a scope
+		if userID == "" {
+			status = "400"
+			http.Error(w, "Idempotency-Key requires X-User-ID", http.StatusBadRequest)
+			return
+		}
+		fingerprint := requestFingerprint(req)
+		var existing *idempotencyRecord
+		claim, existing, err = claimIdempotencyKey(userID, idemKey, fingerprint, jobID)
+		if err != nil {
+			log.Printf("Idempotency claim failed: %v", err)
+			status = "500"
+			http.Error(w, "Internal Storage Error", http.StatusInternalServerError)
+			return
+		}
+		if existing != nil {
+			status = strconv.Itoa(writeIdempotentReplay(w, existing, fingerprint))
+			return
+		}
+	}
+
 	observability.JobsCreatedTotal.WithLabelValues(userID).Inc()
 
 	// S3 Offloading Logic
-	payload := req.Payload
 	if len(payload) > 1024 { // Example threshold: offload payloads larger than 1KB
 		s3Start := time.Now()
 		key := fmt.Sprintf("payloads/%s", jobID)
@@ -303,6 +411,7 @@ func submitHandler(w http.ResponseWriter, r *http.Request) {
 
 		if err != nil {
 			log.Printf("Failed to upload payload to S3: %v", err)
+			claim.release()
 			status = "500"
 			http.Error(w, "Failed to store payload", http.StatusInternalServerError)
 			return
@@ -312,78 +421,77 @@ func submitHandler(w http.ResponseWriter, r *http.Request) {
 		observability.PayloadStorageDuration.WithLabelValues("scylla").Observe(0) // Record a tiny duration for direct storage
 	}
 
-	// Parse NextFireAt
-	var nextFireAt time.Time
-	var err error
-	if req.NextFireAt != "" {
-		nextFireAt, err = time.Parse(time.RFC3339, req.NextFireAt)
-		if err != nil {
-			status = "400"
-			http.Error(w, "Invalid next_fire_at format (RFC3339 required)", http.StatusBadRequest)
-			return
-		}
-	} else {
-		nextFireAt = time.Now()
+	// The submission event is built up front so it can be stored in the
+	// outbox together with the job.
+	event := map[string]interface{}{
+		"job_id":          jobID,
+		"project_id":      req.ProjectID,
+		"user_id":         userID,
+		"next_fire_at":    nextFireAt.Format(time.RFC3339Nano), // job_queue is keyed on it: keep jobs.next_fire_at's milliseconds
+		"submitted_at":    now.Format(time.RFC3339),
+		"shard_id":        shardID,
+		"job_type":        jobType,
+		"payload":         payload, // This will be the S3 reference if offloaded
+		"max_retries":     req.MaxRetries,
+		"timeout_seconds": req.TimeoutSeconds,
 	}
+	eventBytes, _ := json.Marshal(event)
+	entry := outbox.NewEntry(jobID, now, eventBytes)
 
-	// 1. Persist to Scylla (Main Table)
-	query := `INSERT INTO jobs (job_id, project_id, user_id, payload, cron_schedule, next_fire_at, status, created_at, updated_at, max_retries, retry_count, shard_id) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
-	err = scyllaClient.Session.Query(query,
+	// 1. Persist job, user lookup and outbox row atomically (logged batch)
+	batch := scyllaClient.Session.NewBatch(gocql.LoggedBatch)
+	query := `INSERT INTO jobs (job_id, project_id, user_id, job_type, payload, cron_schedule, next_fire_at, occurrence_at, status, created_at, updated_at, max_retries, retry_count, shard_id, timeout_seconds, misfire_policy, max_catchup, timezone) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	batch.Query(query,
 		jobID,
 		req.ProjectID,
 		userID,
+		jobType,
 		payload,
 		req.CronSchedule,
 		nextFireAt,
+		nextFireAt, // occurrence_at
 		"PENDING",
 		now, // created_at
 		now, // updated_at
 		req.MaxRetries,
 		0, // retry_count
-		shardID).Exec()
+		shardID,
+		req.TimeoutSeconds,
+		misfirePolicy,
+		req.MaxCatchup,
+		timezone)
 
-	if err != nil {
+	// Manual index for /jobs lookups
+	if userID != "" {
+		userQuery := `INSERT INTO user_jobs (user_id, created_at, job_id, project_id, status, next_fire_at) VALUES (?, ?, ?, ?, ?, ?)`
+		batch.Query(userQuery, userID, now, jobID, req.ProjectID, "PENDING", nextFireAt)
+	}
+
+	batch.Query(outbox.InsertStatement, entry.InsertValues()...)
+
+	if err := scyllaClient.Session.ExecuteBatch(batch); err != nil {
 		log.Printf("Scylla write to jobs failed: %v", err)
+		claim.release()
 		status = "500"
 		http.Error(w, "Internal Storage Error", http.StatusInternalServerError)
 		return
 	}
+	claim.complete(http.StatusCreated)
 
-	// 1.5 Persist to User Lookup Table (Manual Index)
-	if userID != "" {
-		userQuery := `INSERT INTO user_jobs (user_id, created_at, job_id, status, next_fire_at) VALUES (?, ?, ?, ?, ?)`
-		err = scyllaClient.Session.Query(userQuery, userID, now, jobID, "PENDING", nextFireAt).Exec()
-		if err != nil {
-			log.Printf("Failed to write to user_jobs (non-fatal): %v", err)
-			// Proceeding because main write succeeded
-		}
-	}
-
-	// 2. Publish to Kafka
-	event := map[string]interface{}{
-		"job_id":       jobID,
-		"project_id":   req.ProjectID,
-		"user_id":      userID,
-		"next_fire_at": nextFireAt.Format(time.RFC3339),
-		"submitted_at": now.Format(time.RFC3339),
-		"shard_id":     shardID,
-		"payload":      payload, // This will be the S3 reference if offloaded
-		"max_retries":  req.MaxRetries,
-	}
-	eventBytes, _ := json.Marshal(event)
-
+	// 2. Publish to Kafka. The job is durable from here on: if this fails the
+	// relay publishes it from the outbox, so the request still succeeds.
 	kafkaStart := time.Now()
-	err = kafkaProducer.Publish(jobID, eventBytes)
+	err = kafkaProducer.PublishWithTimeout(jobID, eventBytes, inlinePublishTimeout)
 	observability.KafkaPublishDuration.Observe(time.Since(kafkaStart).Seconds())
 
 	if err != nil {
-		log.Printf("Kafka publish failed: %v", err)
+		log.Printf("Kafka publish failed for job %s, leaving it to the outbox relay: %v", jobID, err)
 		observability.KafkaPublishErrors.Inc()
-		// Note: In a real system, we might want to rollback Scylla or mark as error,
-		// but for now we proceed.
-		status = "500"
-		http.Error(w, "Internal Messaging Error", http.StatusInternalServerError)
-		return
+	} else if err := outbox.Delete(scyllaClient.Session, entry); err != nil {
+		// Worst case the relay publishes it again; the picker drops the duplicate queue row
+		log.Printf("Failed to delete outbox entry of job %s: %v", jobID, err)
+	} else {
+		observability.OutboxPublishedTotal.WithLabelValues("inline").Inc()
 	}
 
 	w.Header().Set("Content-Type", "application/json")
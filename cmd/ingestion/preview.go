@@ -0,0 +1,177 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/robfig/cron/v3"
+
+	"distributed_job_scheduler/pkg/observability"
+	"distributed_job_scheduler/pkg/schedule"
+)
+
+const (
+	defaultPreviewCount = 10
+	maxPreviewCount     = 100
+)
+
+// schedulePreview is the body of both preview endpoints. Fire times are
+// formatted in the schedule's zone so the local wall-clock time is visible.
+// RetryAt is only set for a retrying job: the pending retry of its current
+// occurrence, which is not itself an occurrence of the schedule.
+type schedulePreview struct {
+	JobID        string      `json:"job_id,omitempty"`
+	CronSchedule string      `json:"cron_schedule,omitempty"`
+	Timezone     string      `json:"timezone"`
+	RetryAt      *time.Time  `json:"retry_at,omitempty"`
+	FireTimes    []time.Time `json:"fire_times"`
+}
+
+// previewCount parses the optional count query parameter.
+func previewCount(r *http.Request) (int, *fieldError) {
+	v := r.URL.Query().Get("count")
+	if v == "" {
+		return defaultPreviewCount, nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 || n > maxPreviewCount {
+		return 0, &fieldError{"invalid_count", "count", "count must be between 1 and " + strconv.Itoa(maxPreviewCount)}
+	}
+	return n, nil
+}
+
+// upcoming lists the first n fire times of sched after from, in loc.
+func upcoming(sched cron.Schedule, from time.Time, n int, loc *time.Location) []time.Time {
+	times := make([]time.Time, 0, n)
+	for t := from; len(times) < n; {
+		if t = sched.Next(t); t.IsZero() {
+			break
+		}
+		times = append(times, t.In(loc))
+	}
+	return times
+}
+
+// schedulePreviewHandler serves GET /schedule/preview?cron=...&tz=...&count=N.
+// The expression goes through the same validation as /submit, so a preview
+// that succeeds is a schedule the API will accept.
+func schedulePreviewHandler(w http.ResponseWriter, r *http.Request) {
+	status := "200"
+	start := time.Now()
+	defer func() {
+		observability.HttpRequestDuration.WithLabelValues(r.Method, "/schedule/preview").Observe(time.Since(start).Seconds())
+		observability.HttpRequestsTotal.WithLabelValues(r.Method, "/schedule/preview", status).Inc()
+	}()
+
+	q := r.URL.Query()
+	spec, tz := q.Get("cron"), q.Get("tz")
+	if spec == "" {
+		status = "400"
+		writeFieldError(w, &fieldError{"missing_cron", "cron", "cron is required"})
+		return
+	}
+	count, fe := previewCount(r)
+	if fe == nil {
+		fe = checkTimezone(tz, "tz")
+	}
+	var sched cron.Schedule
+	if fe == nil {
+		sched, fe = parseCron(spec, tz, "cron")
+	}
+	if fe != nil {
+		status = "400"
+		writeFieldError(w, fe)
+		return
+	}
+
+	loc, _ := schedule.LoadLocation(tz)
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedulePreview{
+		CronSchedule: spec,
+		Timezone:     loc.String(),
+		FireTimes:    upcoming(sched, time.Now(), count, loc),
+	})
+}
+
+// jobUpcomingHandler serves GET /job/{id}/upcoming?count=N: the queued fire
+// time followed by the occurrences after it. A retrying job lists its retry
+// as retry_at and the occurrences after the one being retried, since that is
+// what the worker reschedules from. Jobs that will not fire again (finished,
+// failed, cancelled or paused) return an empty list.
+func jobUpcomingHandler(w http.ResponseWriter, r *http.Request) {
+	const path = "/job/{id}/upcoming"
+	status := "200"
+	start := time.Now()
+	defer func() {
+		observability.HttpRequestDuration.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+		observability.HttpRequestsTotal.WithLabelValues(r.Method, path, status).Inc()
+	}()
+
+	jobID := r.PathValue("id")
+	if s := authorizeJobRead(w, r, jobID); s != "" {
+		status = s
+		return
+	}
+	count, fe := previewCount(r)
+	if fe != nil {
+		status = "400"
+		writeFieldError(w, fe)
+		return
+	}
+
+	var cronSchedule, timezone, jobStatus string
+	var nextFireAt, occurrenceAt time.Time
+	query := `SELECT cron_schedule, timezone, status, next_fire_at, occurrence_at FROM jobs WHERE job_id = ?`
+	err := scyllaClient.Session.Query(query, jobID).Scan(&cronSchedule, &timezone, &jobStatus, &nextFireAt, &occurrenceAt)
+	if err == gocql.ErrNotFound {
+		status = "404"
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Scylla query failed: %v", err)
+		status = "500"
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	loc, err := schedule.LoadLocation(timezone)
+	if err != nil {
+		// Stored zones were validated on submit; only a tzdata change gets here
+		log.Printf("Job %s has unusable timezone %q: %v", jobID, timezone, err)
+		loc = time.UTC
+	}
+	resp := schedulePreview{JobID: jobID, CronSchedule: cronSchedule, Timezone: loc.String(), FireTimes: []time.Time{}}
+
+	// from is the time the following occurrences are counted after
+	from, n := nextFireAt, count-1
+	switch jobStatus {
+	case "PENDING":
+		resp.FireTimes = append(resp.FireTimes, nextFireAt.In(loc))
+	case "RETRYING":
+		retryAt := nextFireAt.In(loc)
+		resp.RetryAt = &retryAt
+		from, n = occurrenceAt, count
+		if from.IsZero() {
+			// Jobs created before occurrence_at existed
+			from = nextFireAt
+		}
+	default:
+		n = 0
+	}
+	if cronSchedule != "" && n > 0 {
+		sched, err := schedule.ParseIn(cronSchedule, timezone)
+		if err != nil {
+			log.Printf("Job %s has unparseable cron schedule %q: %v", jobID, cronSchedule, err)
+		} else {
+			resp.FireTimes = append(resp.FireTimes, upcoming(sched, from, n, loc)...)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/gocql/gocql"
+
+	"distributed_job_scheduler/pkg/observability"
+)
+
+const (
+	defaultRunsLimit = 20
+	maxRunsLimit     = 200
+)
+
+// Bucket the worker offloads large run output to.
+const runOutputBucket = "job-outputs"
+
+// runRecord is one job_runs row. Output is only included by the single-run
+// endpoint; listings stay small regardless of how chatty the job is. Output
+// over 1KB is stored in S3: Output then holds a preview, OutputRef the object
+// and GET .../output streams the whole thing.
+type runRecord struct {
+	RunID        string            `json:"run_id"`
+	Status       string            `json:"status"`
+	Attempt      int               `json:"attempt"`
+	WorkerID     string            `json:"worker_id,omitempty"`
+	ErrorMessage string            `json:"error_message,omitempty"`
+	KillSignal   string            `json:"kill_signal,omitempty"`
+	ExitCode     *int              `json:"exit_code,omitempty"`
+	TermSignal   string            `json:"term_signal,omitempty"`
+	DurationMs   int64             `json:"duration_ms"`
+	ScheduledFor *time.Time        `json:"scheduled_for,omitempty"`
+	DispatchedAt *time.Time        `json:"dispatched_at,omitempty"`
+	StartedAt    *time.Time        `json:"started_at,omitempty"`
+	TriggeredAt  *time.Time        `json:"triggered_at,omitempty"`
+	CompletedAt  *time.Time        `json:"completed_at,omitempty"`
+	OutputBytes  int64             `json:"output_bytes"`
+	HTTPStatus   int               `json:"http_status,omitempty"`
+	HTTPHeaders  map[string]string `json:"response_headers,omitempty"`
+	Output       *string           `json:"output,omitempty"`
+	OutputRef    string            `json:"output_ref,omitempty"`
+	Truncated    bool              `json:"output_truncated,omitempty"`
+}
+
+const runColumns = `run_id, status, attempt, worker_id, error_message, kill_signal, exit_code, term_signal, duration_ms, scheduled_for, dispatched_at, started_at, triggered_at, completed_at, output_bytes, http_status`
+
+// scanRun reads runColumns (plus output, output_ref and response_headers when
+// withOutput) from scan.
+func scanRun(scan func(...interface{}) bool, withOutput bool) (runRecord, bool) {
+	var rec runRecord
+	var runID gocql.UUID
+	var scheduledFor, dispatchedAt, startedAt, triggeredAt, completedAt time.Time
+	var output string
+	dest := []interface{}{&runID, &rec.Status, &rec.Attempt, &rec.WorkerID, &rec.ErrorMessage, &rec.KillSignal, &rec.ExitCode, &rec.TermSignal, &rec.DurationMs,
+		&scheduledFor, &dispatchedAt, &startedAt, &triggeredAt, &completedAt, &rec.OutputBytes, &rec.HTTPStatus}
+	if withOutput {
+		dest = append(dest, &output, &rec.OutputRef, &rec.HTTPHeaders)
+	}
+	if !scan(dest...) {
+		return rec, false
+	}
+
+	rec.RunID = runID.String()
+	rec.ScheduledFor = optionalTime(scheduledFor)
+	rec.DispatchedAt = optionalTime(dispatchedAt)
+	rec.StartedAt = optionalTime(startedAt)
+	rec.TriggeredAt = optionalTime(triggeredAt)
+	rec.CompletedAt = optionalTime(completedAt)
+	if withOutput {
+		rec.Output = &output
+		if rec.OutputBytes == 0 {
+			// Rows written before output_bytes existed hold the whole output
+			rec.OutputBytes = int64(len(output))
+		}
+		rec.Truncated = int64(len(output)) < rec.OutputBytes
+	}
+	return rec, true
+}
+
+// optionalTime maps a null timestamp (scanned as the zero time) to nil.
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// authorizeJobRead checks the job exists and, for jobs submitted with a user,
+// that the caller is that user. It writes the error response itself and
+// returns the HTTP status for metrics ("" when access is allowed).
+func authorizeJobRead(w http.ResponseWriter, r *http.Request, jobID string) string {
+	if _, err := gocql.ParseUUID(jobID); err != nil {
+		http.Error(w, "Invalid job id", http.StatusBadRequest)
+		return "400"
+	}
+
+	var ownerID string
+	err := scyllaClient.Session.Query(`SELECT user_id FROM jobs WHERE job_id = ?`, jobID).Scan(&ownerID)
+	if err == gocql.ErrNotFound {
+		http.Error(w, "Job not found", http.StatusNotFound)
+		return "404"
+	}
+	if err != nil {
+		log.Printf("Scylla query failed: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return "500"
+	}
+	if ownerID != "" && r.Header.Get("X-User-ID") != ownerID {
+		http.Error(w, "Job belongs to another user", http.StatusForbidden)
+		return "403"
+	}
+	return ""
+}
+
+// listRunsHandler serves GET /job/{id}/runs?limit=N&cursor=..., newest first
+// (run ids are time-based UUIDs and job_runs clusters them descending). The
+// first page also lists runs that are still executing, as RUNNING.
+func listRunsHandler(w http.ResponseWriter, r *http.Request) {
+	const path = "/job/{id}/runs"
+	status := "200"
+	start := time.Now()
+	defer func() {
+		observability.HttpRequestDuration.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+		observability.HttpRequestsTotal.WithLabelValues(r.Method, path, status).Inc()
+	}()
+
+	jobID := r.PathValue("id")
+	if s := authorizeJobRead(w, r, jobID); s != "" {
+		status = s
+		return
+	}
+
+	limit := defaultRunsLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 || n > maxRunsLimit {
+			status = "400"
+			writeFieldError(w, &fieldError{"invalid_limit", "limit", "limit must be between 1 and " + strconv.Itoa(maxRunsLimit)})
+			return
+		}
+		limit = n
+	}
+	scope := cursorScope("runs", jobID)
+	var pageState []byte
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		var ok bool
+		if pageState, ok = decodeCursor(scope, v); !ok {
+			status = "400"
+			writeFieldError(w, &fieldError{"invalid_cursor", "cursor", "cursor is malformed or belongs to another job"})
+			return
+		}
+	}
+
+	query := `SELECT ` + runColumns + ` FROM job_runs WHERE job_id = ?`
+	iter := scyllaClient.Session.Query(query, jobID).PageSize(limit).PageState(pageState).Iter()
+	nextPage := iter.PageState()
+
+	runs := []runRecord{}
+	for n := iter.NumRows(); n > 0; n-- {
+		rec, ok := scanRun(iter.Scan, false)
+		if !ok {
+			break
+		}
+		runs = append(runs, rec)
+	}
+	if err := iter.Close(); err != nil {
+		log.Printf("Scylla iteration failed: %v", err)
+		status = "500"
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	if pageState == nil {
+		// Runs still executing aren't in job_runs yet; they are the newest
+		recorded := map[string]bool{}
+		for _, run := range runs {
+			recorded[run.RunID] = true
+		}
+		var live []runRecord
+		for _, run := range liveRuns(r.Context(), jobID) {
+			if !recorded[run.RunID] {
+				live = append(live, run)
+			}
+		}
+		runs = append(live, runs...)
+	}
+
+	resp := map[string]interface{}{"runs": runs}
+	if cursor := encodeCursor(scope, nextPage); cursor != "" {
+		resp["next_cursor"] = cursor
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// getRunHandler serves GET /job/{id}/runs/{run_id}, including the run's output.
+func getRunHandler(w http.ResponseWriter, r *http.Request) {
+	const path = "/job/{id}/runs/{run_id}"
+	status := "200"
+	start := time.Now()
+	defer func() {
+		observability.HttpRequestDuration.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+		observability.HttpRequestsTotal.WithLabelValues(r.Method, path, status).Inc()
+	}()
+
+	jobID, runID := r.PathValue("id"), r.PathValue("run_id")
+	if s := authorizeJobRead(w, r, jobID); s != "" {
+		status = s
+		return
+	}
+	if _, err := gocql.ParseUUID(runID); err != nil {
+		status = "400"
+		http.Error(w, "Invalid run id", http.StatusBadRequest)
+		return
+	}
+
+	query := `SELECT ` + runColumns + `, output, output_ref, response_headers FROM job_runs WHERE job_id = ? AND run_id = ?`
+	iter := scyllaClient.Session.Query(query, jobID, runID).Iter()
+	rec, found := scanRun(iter.Scan, true)
+	if err := iter.Close(); err != nil {
+		log.Printf("Scylla query failed: %v", err)
+		status = "500"
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		for _, live := range liveRuns(r.Context(), jobID) {
+			if live.RunID == runID {
+				rec, found = live, true
+			}
+		}
+	}
+	if !found {
+		status = "404"
+		http.Error(w, "Run not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rec)
+}
+
+// runOutputHandler serves GET /job/{id}/runs/{run_id}/output: the complete
+// output as text/plain, streamed from S3 when it was offloaded.
+func runOutputHandler(w http.ResponseWriter, r *http.Request) {
+	const path = "/job/{id}/runs/{run_id}/output"
+	status := "200"
+	start := time.Now()
+	defer func() {
+		observability.HttpRequestDuration.WithLabelValues(r.Method, path).Observe(time.Since(start).Seconds())
+		observability.HttpRequestsTotal.WithLabelValues(r.Method, path, status).Inc()
+	}()
+
+	jobID, runID := r.PathValue("id"), r.PathValue("run_id")
+	if s := authorizeJobRead(w, r, jobID); s != "" {
+		status = s
+		return
+	}
+	if _, err := gocql.ParseUUID(runID); err != nil {
+		status = "400"
+		http.Error(w, "Invalid run id", http.StatusBadRequest)
+		return
+	}
+
+	var output, outputRef string
+	var outputBytes int64
+	query := `SELECT output, output_ref, output_bytes FROM job_runs WHERE job_id = ? AND run_id = ?`
+	err := scyllaClient.Session.Query(query, jobID, runID).Scan(&output, &outputRef, &outputBytes)
+	if err == gocql.ErrNotFound {
+		status = "404"
+		http.Error(w, "Run not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Scylla query failed: %v", err)
+		status = "500"
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	if !strings.HasPrefix(outputRef, "s3:") {
+		if outputBytes > int64(len(output)) {
+			// The S3 upload failed when the run finished; only the preview exists
+			w.Header().Set("X-Output-Truncated", "true")
+		}
+		io.WriteString(w, output)
+		return
+	}
+
+	observability.S3OperationsTotal.WithLabelValues("download").Inc()
+	obj, err := s3Client.Client.GetObject(r.Context(), &s3.GetObjectInput{
+		Bucket: aws.String(runOutputBucket),
+		Key:    aws.String(strings.TrimPrefix(outputRef, "s3:")),
+	})
+	if err != nil {
+		log.Printf("Failed to fetch output %s from S3: %v", outputRef, err)
+		status = "502"
+		http.Error(w, "Failed to fetch output from storage", http.StatusBadGateway)
+		return
+	}
+	defer obj.Body.Close()
+
+	if obj.ContentLength != nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(*obj.ContentLength, 10))
+	}
+	if _, err := io.Copy(w, obj.Body); err != nil {
+		// Headers are already sent; the client sees a short body
+		log.Printf("Streaming output %s interrupted: %v", outputRef, err)
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"distributed_job_scheduler/pkg/executor"
+	"distributed_job_scheduler/pkg/schedule"
+)
+
+// fieldError is the JSON body of a 400 caused by one bad request field:
+//
+//	{"error": {"code": "invalid_cron_schedule", "field": "cron_schedule", "message": "..."}}
+type fieldError struct {
+	Code    string `json:"code"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func writeFieldError(w http.ResponseWriter, fe *fieldError) {
+	writeFieldErrorStatus(w, http.StatusBadRequest, fe)
+}
+
+func writeFieldErrorStatus(w http.ResponseWriter, code int, fe *fieldError) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(map[string]*fieldError{"error": fe})
+}
+
+// validateSchedule checks the scheduling fields of req and resolves the first
+// fire time. The cron expression is parsed with the same parser the worker
+// reschedules with, in the job's timezone, so anything accepted here keeps
+// recurring. Without an
+// explicit next_fire_at, a recurring job first fires at its next occurrence
+// after now and a one-off job fires immediately.
+func validateSchedule(req JobRequest, now time.Time) (time.Time, *fieldError) {
+	if req.TimeoutSeconds < 0 {
+		return time.Time{}, &fieldError{"invalid_timeout", "timeout_seconds", "timeout_seconds must not be negative"}
+	}
+	if !schedule.ValidMisfirePolicy(req.MisfirePolicy) {
+		return time.Time{}, &fieldError{"invalid_misfire_policy", "misfire_policy", "misfire_policy must be one of fire_once, fire_all, skip"}
+	}
+	if req.MaxCatchup < 0 {
+		return time.Time{}, &fieldError{"invalid_max_catchup", "max_catchup", "max_catchup must not be negative"}
+	}
+
+	if fe := checkTimezone(req.Timezone, "timezone"); fe != nil {
+		return time.Time{}, fe
+	}
+
+	var next time.Time
+	if req.CronSchedule != "" {
+		sched, fe := parseCron(req.CronSchedule, req.Timezone, "cron_schedule")
+		if fe != nil {
+			return time.Time{}, fe
+		}
+		next = sched.Next(now)
+	}
+
+	if req.NextFireAt != "" {
+		t, err := time.Parse(time.RFC3339, req.NextFireAt)
+		if err != nil {
+			return time.Time{}, &fieldError{"invalid_next_fire_at", "next_fire_at", "next_fire_at must be an RFC3339 timestamp"}
+		}
+		return t, nil
+	}
+	if next.IsZero() {
+		next = now
+	}
+	return next, nil
+}
+
+// checkTimezone validates an IANA zone name; field names the request field
+// (or query parameter) it came from.
+func checkTimezone(tz, field string) *fieldError {
+	if _, err := schedule.LoadLocation(tz); err != nil {
+		return &fieldError{"invalid_timezone", field,
+			fmt.Sprintf("%s must be an IANA time zone name such as Europe/Berlin: %v", field, err)}
+	}
+	return nil
+}
+
+// parseCron parses spec in tz exactly as the worker will, rejecting
+// expressions that never fire.
+func parseCron(spec, tz, field string) (cron.Schedule, *fieldError) {
+	sched, err := schedule.ParseIn(spec, tz)
+	if err != nil {
+		return nil, &fieldError{"invalid_cron_schedule", field,
+			fmt.Sprintf("%v (expected 6 fields: second minute hour day-of-month month day-of-week, or a descriptor such as @every 5m)", err)}
+	}
+	if sched.Next(time.Now()).IsZero() {
+		return nil, &fieldError{"invalid_cron_schedule", field, field + " never fires"}
+	}
+	return sched, nil
+}
+
+// validateJobType resolves the executor for req and returns the job type and
+// the payload to store (a legacy "<type>:" prefix is stripped once the type
+// is known). Unknown types are rejected here rather than failing every run.
+func validateJobType(req JobRequest) (string, string, *fieldError) {
+	jobType, payload, err := executor.Resolve(req.JobType, req.Payload)
+	if err != nil {
+		return "", "", &fieldError{"invalid_job_type", "job_type", err.Error()}
+	}
+	if err := executor.Validate(jobType, payload); err != nil {
+		return "", "", &fieldError{"invalid_payload", "payload", err.Error()}
+	}
+	return jobType, payload, nil
+}
+
+// authorizeJobType rejects job types the submitting project may not use, such
+// as cmd for projects missing from CMD_ALLOWED_PROJECTS.
+func authorizeJobType(req JobRequest, jobType string) *fieldError {
+	if err := executor.Authorize(jobType, req.ProjectID); err != nil {
+		return &fieldError{"job_type_not_allowed", "project_id", err.Error()}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// How long a DISPATCHING claim is honoured. A picker that dies between
+// claiming a row and deleting it leaves the claim behind; once the lease
+// has passed, any picker owning the shard re-dispatches the row.
+var claimLease = claimLeaseFromEnv()
+
+// Written to job_queue.owner on claim; set from the picker ID at startup.
+var claimOwner string
+
+func claimLeaseFromEnv() time.Duration {
+	if v := os.Getenv("CLAIM_LEASE"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("Invalid CLAIM_LEASE %q, using default", v)
+	}
+	return 30 * time.Second
+}
+
+// queueRow is a due job_queue row as read by scanShard.
+type queueRow struct {
+	ShardID    int
+	FireAt     time.Time
+	ID         gocql.UUID
+	Status     string
+	Owner      string
+	LeaseUntil time.Time
+	RunID      gocql.UUID
+}
+
+// claimable reports whether r is free to claim: PENDING, or DISPATCHING
+// with an expired lease.
+func (r queueRow) claimable(now time.Time) bool {
+	if r.Owner == "" {
+		return r.Status == "PENDING"
+	}
+	return now.After(r.LeaseUntil)
+}
+
+// claim moves r to DISPATCHING under our name with a lightweight
+// transaction, so overlapping scans or a second picker can't both send it.
+// A stale claim is taken over by matching the exact owner and lease we saw.
+// The run_id is stored with the claim so a re-dispatch reuses it.
+func claim(r *queueRow) (bool, error) {
+	if r.RunID == (gocql.UUID{}) {
+		r.RunID = gocql.TimeUUID()
+	}
+	leaseUntil := time.Now().Add(claimLease)
+
+	var query *gocql.Query
+	if r.Owner == "" {
+		query = scyllaClient.Session.Query(`UPDATE job_queue SET status = ?, owner = ?, lease_until = ?, run_id = ? WHERE shard_id = ? AND next_fire_at = ? AND job_id = ? IF status = ? AND owner = null`,
+			"DISPATCHING", claimOwner, leaseUntil, r.RunID, r.ShardID, r.FireAt, r.ID, "PENDING")
+	} else {
+		query = scyllaClient.Session.Query(`UPDATE job_queue SET status = ?, owner = ?, lease_until = ?, run_id = ? WHERE shard_id = ? AND next_fire_at = ? AND job_id = ? IF owner = ? AND lease_until = ?`,
+			"DISPATCHING", claimOwner, leaseUntil, r.RunID, r.ShardID, r.FireAt, r.ID, r.Owner, r.LeaseUntil)
+	}
+
+	applied, err := query.MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return false, err
+	}
+	if applied && r.Owner != "" {
+		log.Printf("Took over stale claim on job %s from %s (lease expired %s)", r.ID, r.Owner, r.LeaseUntil.Format(time.RFC3339))
+	}
+	return applied, nil
+}
+
+// release hands a claimed row back as PENDING, e.g. after a failed send.
+func release(r queueRow) {
+	query := `UPDATE job_queue SET status = ?, owner = null, lease_until = null WHERE shard_id = ? AND next_fire_at = ? AND job_id = ? IF owner = ?`
+	if _, err := scyllaClient.Session.Query(query, "PENDING", r.ShardID, r.FireAt, r.ID, claimOwner).MapScanCAS(map[string]interface{}{}); err != nil {
+		// The lease runs out on its own
+		log.Printf("Failed to release claim on job %s: %v", r.ID, err)
+	}
+}
+
+// complete deletes a dispatched row, but only while we still hold the claim.
+func complete(r queueRow) {
+	query := `DELETE FROM job_queue WHERE shard_id = ? AND next_fire_at = ? AND job_id = ? IF owner = ?`
+	applied, err := scyllaClient.Session.Query(query, r.ShardID, r.FireAt, r.ID, claimOwner).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		log.Printf("Failed to delete job from queue: %v", err)
+		return
+	}
+	if !applied {
+		log.Printf("Claim on job %s was lost before delete (lease %v too short?)", r.ID, claimLease)
+	}
+}
+
+// queuedForCurrentFire reports whether r is the queue row of the job's
+// current occurrence. Rows queued from submission events that carried whole
+// seconds are matched against next_fire_at truncated to the second.
+func queuedForCurrentFire(r queueRow, jobStatus string, nextFireAt time.Time) bool {
+	if jobStatus != "PENDING" && jobStatus != "RETRYING" {
+		return false
+	}
+	return r.FireAt.Equal(nextFireAt) || r.FireAt.Equal(nextFireAt.Truncate(time.Second))
+}
+
+// recordDispatch stores r's run as the one dispatched for the occurrence at
+// fireAt (jobs.next_fire_at) and reports whether that occurrence already went
+// out under another run. prevFireAt and prevRunID are the job's
+// dispatched_fire_at and dispatched_run_id as read before claiming; the LWT
+// makes two rows for one occurrence race for it rather than both dispatch.
+func recordDispatch(r queueRow, fireAt, prevFireAt time.Time, prevRunID gocql.UUID) (bool, error) {
+	// Our own run again: re-dispatch after a failed send or a stale claim
+	if prevRunID == r.RunID {
+		return false, nil
+	}
+	if prevRunID != (gocql.UUID{}) && prevFireAt.Equal(fireAt) {
+		return true, nil
+	}
+
+	var query *gocql.Query
+	if prevRunID == (gocql.UUID{}) {
+		query = scyllaClient.Session.Query(`UPDATE jobs SET dispatched_fire_at = ?, dispatched_run_id = ? WHERE job_id = ? IF next_fire_at = ? AND dispatched_run_id = null`,
+			fireAt, r.RunID, r.ID, fireAt)
+	} else {
+		query = scyllaClient.Session.Query(`UPDATE jobs SET dispatched_fire_at = ?, dispatched_run_id = ? WHERE job_id = ? IF next_fire_at = ? AND dispatched_run_id = ?`,
+			fireAt, r.RunID, r.ID, fireAt, prevRunID)
+	}
+	applied, err := query.MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		return false, err
+	}
+	// Not applied: another row of this occurrence won, or the job moved on
+	return !applied, nil
+}
@@ -11,7 +11,7 @@ import (
 
     "distributed_job_scheduler/pkg/infra"
     "distributed_job_scheduler/pkg/observability"
-    "github.com/google/uuid"
+    "distributed_job_scheduler/pkg/shutdown"
     "github.com/gocql/gocql"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"net/http"
@@ -21,6 +21,7 @@ var (
     scyllaClient  *infra.ScyllaClient
     sqsClient     *infra.SQSClient
     etcdClient    *infra.EtcdClient
+    ownership     = &shardOwnership{}
 )
 
 func main() {
@@ -29,17 +30,48 @@ func main() {
 	initInfra()
 	defer closeInfra()
 
+	ctx, stop := shutdown.NotifyContext()
+	defer stop()
+	grace := shutdown.GracePeriod()
+
 	// Start metrics endpoint
+	metricshttp := http.NewServeMux()
+	metricshttp.Handle("/metrics", promhttp.Handler())
+	log.Println("Metrics endpoint listening on :8082")
+	metricsDone := shutdown.ListenAndServe(ctx, &http.Server{Addr: ":8082", Handler: metricshttp}, grace)
+
+	pickerID := pickerIdentity()
+	claimOwner = pickerID
+	session, err := register(ctx, pickerID)
+	if err != nil {
+		log.Fatalf("Failed to register picker: %v", err)
+	}
+
+	// Losing the lease means the coordinator will hand our shards to
+	// someone else; stop dispatching immediately and let the pod restart.
 	go func() {
-		metricshttp := http.NewServeMux()
-		metricshttp.Handle("/metrics", promhttp.Handler())
-		log.Println("Metrics endpoint listening on :8082")
-		if err := http.ListenAndServe(":8082", metricshttp); err != nil {
-			log.Printf("Metrics server failed: %v", err)
+		select {
+		case <-session.Done():
+			log.Println("Etcd session expired, releasing shards...")
+			ownership.Set(nil)
+			os.Exit(1)
+		case <-ctx.Done():
 		}
 	}()
 
-	runPickerLoop()
+	go watchAssignments(ctx, pickerID, ownership)
+
+	runPickerLoop(ctx)
+
+	// Stop dispatching before the lease goes, then revoke it so the
+	// coordinator reassigns our shards now rather than after the TTL.
+	log.Println("Shutdown signal received, releasing shards...")
+	ownership.Set(nil)
+	if err := session.Close(); err != nil {
+		log.Printf("Failed to revoke picker lease: %v", err)
+	}
+	<-metricsDone
+	log.Println("Picker Service stopped")
 }
 
 func initInfra() {
@@ -82,42 +114,79 @@ func closeInfra() {
     if etcdClient != nil { etcdClient.Close() }
 }
 
-func runPickerLoop() {
+// runPickerLoop scans owned shards every second until ctx is cancelled.
+// Shards are scanned on a bounded pool and a tick waits for all of them, so
+// a slow cycle delays the next one instead of overlapping it. A shard scan
+// in progress always runs to completion.
+func runPickerLoop(ctx context.Context) {
     ticker := time.NewTicker(1 * time.Second)
     defer ticker.Stop()
 
     for {
-        <-ticker.C
-        // For Phase 4.3, assume we own ALL shards (0-1023)
-        // In real impl, we fetch assignments from Etcd
-        for shardID := 0; shardID < 1024; shardID++ {
+        select {
+        case <-ctx.Done():
+            return
+        case <-ticker.C:
+        }
+
+        cycleStart := time.Now()
+        shards := ownership.Snapshot()
+        oldest := make([]time.Time, len(shards))
+
+        forEachLimit(len(shards), scanConcurrency, func(i int) {
+             shardID := shards[i]
+             // Assignment may have changed since the snapshot was taken
+             if ctx.Err() != nil || !ownership.Owns(shardID) {
+                 return
+             }
              start := time.Now()
-             scanShard(shardID)
+             oldest[i] = scanShard(shardID)
              observability.ScanCycleDuration.WithLabelValues(strconv.Itoa(shardID)).Observe(time.Since(start).Seconds())
              observability.PickerScansTotal.WithLabelValues(strconv.Itoa(shardID)).Inc()
+        })
+
+        observability.PickerCycleDuration.Observe(time.Since(cycleStart).Seconds())
+        observability.PickerOldestDueSeconds.Set(oldestDueAge(oldest, cycleStart))
+    }
+}
+
+// oldestDueAge is how far behind real time the most overdue row seen in a
+// cycle was; 0 when nothing was due.
+func oldestDueAge(oldest []time.Time, now time.Time) float64 {
+    var age time.Duration
+    for _, t := range oldest {
+        if !t.IsZero() && now.Sub(t) > age {
+            age = now.Sub(t)
         }
     }
+    return age.Seconds()
 }
 
-func scanShard(shardID int) {
+// dispatch is a claimed row with its SQS message body.
+type dispatch struct {
+    row  queueRow
+    body string
+}
+
+// scanShard dispatches every due row of a shard and returns the fire time of
+// the oldest one (zero if none were due).
+func scanShard(shardID int) time.Time {
     now := time.Now()
     // 1. Get candidate jobs from job_queue (metadata only)
-    query := `SELECT job_id, next_fire_at FROM job_queue WHERE shard_id = ? AND next_fire_at <= ? ALLOW FILTERING`
+    query := `SELECT job_id, next_fire_at, status, owner, lease_until, run_id FROM job_queue WHERE shard_id = ? AND next_fire_at <= ?`
     
     iter := scyllaClient.Session.Query(query, shardID, now).Iter()
     
-    var jobID gocql.UUID
-    var nextFireAt time.Time
-    
     // Store candidates to process
-    type Candidate struct {
-        ID     gocql.UUID
-        FireAt time.Time
-    }
-    var candidates []Candidate
+    var candidates []queueRow
+    row := queueRow{ShardID: shardID}
 
-    for iter.Scan(&jobID, &nextFireAt) {
-        candidates = append(candidates, Candidate{ID: jobID, FireAt: nextFireAt})
+    for iter.Scan(&row.ID, &row.FireAt, &row.Status, &row.Owner, &row.LeaseUntil, &row.RunID) {
+        // Skip rows another scan is dispatching right now
+        if row.claimable(now) {
+            candidates = append(candidates, row)
+        }
+        row = queueRow{ShardID: shardID}
     }
     
     if err := iter.Close(); err != nil {
@@ -125,54 +194,157 @@ func scanShard(shardID int) {
     }
 
     observability.JobsScannedTotal.Add(float64(len(candidates)))
+    if len(candidates) == 0 {
+        return time.Time{}
+    }
+    // Rows come back in next_fire_at order
+    oldest := candidates[0].FireAt
 
-    // 2. Process candidates
-    for _, cand := range candidates {
-        var payload, projectID, cronSchedule string
-        var maxRetries int
-        var userID string
-        
-        // Fetch full details from 'jobs' table
-        // Updated to include user_id and max_retries
-        err := scyllaClient.Session.Query(`SELECT payload, project_id, cron_schedule, user_id, max_retries FROM jobs WHERE job_id = ?`, cand.ID).Scan(&payload, &projectID, &cronSchedule, &userID, &maxRetries)
-        if err != nil {
-            log.Printf("Failed to fetch details for job %s: %v", cand.ID, err)
-            continue
+    // 2. Fetch details and claim candidates concurrently
+    prepared := make([]*dispatch, len(candidates))
+    forEachLimit(len(candidates), fetchConcurrency, func(i int) {
+        // Stop as soon as the coordinator moves this shard elsewhere
+        if !ownership.Owns(shardID) {
+            return
         }
+        prepared[i] = prepareDispatch(candidates[i])
+    })
 
-        log.Printf("Picking job %s (Shard: %d)", cand.ID, shardID)
-        
-        // 3. Publish to SQS
-        event := map[string]interface{}{
-            "job_id": cand.ID.String(),
-            "run_id": uuid.New().String(),
-            "status": "STARTED",
-            "executed_at": time.Now().Format(time.RFC3339),
-            "payload": payload,
-            "project_id": projectID,
-            "cron_schedule": cronSchedule,
-            "user_id": userID,
-            "max_retries": maxRetries,
+    var batch []dispatch
+    for _, d := range prepared {
+        if d != nil {
+            batch = append(batch, *d)
         }
-        eventBytes, _ := json.Marshal(event)
-        
-        log.Printf("[DEBUG] Publishing job %s to SQS (QueueURL: %s, Payload size: %d bytes)", cand.ID, sqsClient.QueueURL, len(eventBytes))
-        sqsStart := time.Now()
-        err = sqsClient.SendMessage(context.TODO(), string(eventBytes))
-        observability.SQSEnqueueDuration.Observe(time.Since(sqsStart).Seconds())
-
-        if err != nil {
-            log.Printf("Failed to publish job execution to SQS: %v", err)
+    }
+    if len(batch) == 0 {
+        return oldest
+    }
+
+    // 3. Publish to SQS in batches
+    bodies := make([]string, len(batch))
+    for i, d := range batch {
+        bodies[i] = d.body
+    }
+    sqsStart := time.Now()
+    errs := sqsClient.SendMessageBatch(context.TODO(), bodies)
+    observability.SQSEnqueueDuration.Observe(time.Since(sqsStart).Seconds())
+    sentAt := time.Now()
+
+    // 4. Delete from job_queue ONLY the rows SQS accepted. If we crash
+    // before this, the claim expires and the row is re-sent with the
+    // same run_id, which the worker skips if that run already finished.
+    forEachLimit(len(batch), fetchConcurrency, func(i int) {
+        d := batch[i]
+        if errs[i] != nil {
+            log.Printf("Failed to publish job %s to SQS: %v", d.row.ID, errs[i])
             observability.SQSEnqueueErrors.Inc()
-            continue // Don't delete from queue if SQS publish failed
+            release(d.row)
+            return
         }
-        log.Printf("[DEBUG] Successfully published job %s to SQS", cand.ID)
         observability.JobsEnqueuedTotal.Inc()
+        observability.DispatchLag.Observe(sentAt.Sub(d.row.FireAt).Seconds())
+        complete(d.row)
+    })
+    log.Printf("Dispatched %d/%d due job(s) from shard %d", countNil(errs), len(candidates), shardID)
 
-        // 4. Delete from job_queue ONLY if SQS publish succeeded
+    return oldest
+}
+
+// prepareDispatch loads the job behind a due row and claims the row. It
+// returns nil if the row should not be sent by this scan.
+func prepareDispatch(cand queueRow) *dispatch {
+    shardID := cand.ShardID
+
+    var payload, projectID, cronSchedule, jobStatus, misfirePolicy, timezone, jobType string
+    var maxRetries, retryCount, timeoutSeconds, maxCatchup int
+    var userID string
+    var occurrenceAt, nextFireAt, dispatchedFireAt time.Time
+    var dispatchedRunID gocql.UUID
+    
+    // Fetch full details from 'jobs' table
+    // Updated to include user_id, retry bookkeeping, misfire settings and the last dispatch
+    err := scyllaClient.Session.Query(`SELECT payload, project_id, cron_schedule, user_id, max_retries, retry_count, status, timeout_seconds, occurrence_at, misfire_policy, max_catchup, timezone, job_type, next_fire_at, dispatched_fire_at, dispatched_run_id FROM jobs WHERE job_id = ?`, cand.ID).Scan(&payload, &projectID, &cronSchedule, &userID, &maxRetries, &retryCount, &jobStatus, &timeoutSeconds, &occurrenceAt, &misfirePolicy, &maxCatchup, &timezone, &jobType, &nextFireAt, &dispatchedFireAt, &dispatchedRunID)
+    if err != nil {
+        log.Printf("Failed to fetch details for job %s: %v", cand.ID, err)
+        return nil
+    }
+
+    // A queue row only stands for the job's current occurrence. Rows the
+    // writer inserted after a cancel/pause, or for an occurrence the job has
+    // moved past (a late or duplicate submission event), are dropped.
+    if !queuedForCurrentFire(cand, jobStatus, nextFireAt) {
+        log.Printf("Dropping queued job %s at %v (status %s, next fire %v)", cand.ID, cand.FireAt, jobStatus, nextFireAt)
         delQuery := `DELETE FROM job_queue WHERE shard_id = ? AND next_fire_at = ? AND job_id = ?`
         if err := scyllaClient.Session.Query(delQuery, shardID, cand.FireAt, cand.ID).Exec(); err != nil {
             log.Printf("Failed to delete job from queue: %v", err)
         }
+        return nil
+    }
+
+    // Claim the row; losing means someone else is dispatching it
+    claimed, err := claim(&cand)
+    if err != nil {
+        log.Printf("Failed to claim job %s: %v", cand.ID, err)
+        return nil
+    }
+    if !claimed {
+        observability.ClaimConflictsTotal.Inc()
+        return nil
+    }
+
+    // The occurrence may already be in SQS under another run: its row was
+    // deleted after dispatch and re-created from a duplicate event
+    duplicate, err := recordDispatch(cand, nextFireAt, dispatchedFireAt, dispatchedRunID)
+    if err != nil {
+        log.Printf("Failed to record dispatch of job %s: %v", cand.ID, err)
+        release(cand)
+        return nil
+    }
+    if duplicate {
+        log.Printf("Dropping queued job %s at %v: occurrence already dispatched", cand.ID, cand.FireAt)
+        complete(cand)
+        return nil
+    }
+
+    log.Printf("Picking job %s (Shard: %d, Run: %s)", cand.ID, shardID, cand.RunID)
+    
+    dispatchedAt := time.Now()
+    event := map[string]interface{}{
+        "job_id": cand.ID.String(),
+        "run_id": cand.RunID.String(),
+        "status": "STARTED",
+        "executed_at": dispatchedAt.Format(time.RFC3339),
+        "scheduled_for": cand.FireAt.Format(time.RFC3339Nano),
+        "dispatched_at": dispatchedAt.Format(time.RFC3339Nano),
+        "job_type": jobType,
+        "payload": payload,
+        "project_id": projectID,
+        "cron_schedule": cronSchedule,
+        "user_id": userID,
+        "max_retries": maxRetries,
+        "retry_count": retryCount,
+        "timeout_seconds": timeoutSeconds,
+    }
+    if cronSchedule != "" {
+        // Jobs created before occurrence_at existed fall back to this row's time
+        if occurrenceAt.IsZero() {
+            occurrenceAt = cand.FireAt
+        }
+        event["occurrence_at"] = occurrenceAt.Format(time.RFC3339Nano)
+        event["misfire_policy"] = misfirePolicy
+        event["max_catchup"] = maxCatchup
+        event["timezone"] = timezone
+    }
+    eventBytes, _ := json.Marshal(event)
+    return &dispatch{row: cand, body: string(eventBytes)}
+}
+
+func countNil(errs []error) int {
+    n := 0
+    for _, err := range errs {
+        if err == nil {
+            n++
+        }
     }
+    return n
 }
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"distributed_job_scheduler/pkg/observability"
+	"distributed_job_scheduler/pkg/sharding"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+)
+
+// shardOwnership is the set of shards this picker may dispatch from.
+// It is replaced wholesale whenever the coordinator rewrites our assignment.
+type shardOwnership struct {
+	mu     sync.RWMutex
+	shards map[int]bool
+}
+
+func (o *shardOwnership) Set(shards []int) {
+	next := make(map[int]bool, len(shards))
+	for _, s := range shards {
+		next[s] = true
+	}
+	o.mu.Lock()
+	o.shards = next
+	o.mu.Unlock()
+	observability.PickerOwnedShards.Set(float64(len(next)))
+}
+
+func (o *shardOwnership) Owns(shardID int) bool {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.shards[shardID]
+}
+
+func (o *shardOwnership) Snapshot() []int {
+	o.mu.RLock()
+	out := make([]int, 0, len(o.shards))
+	for s := range o.shards {
+		out = append(out, s)
+	}
+	o.mu.RUnlock()
+	sort.Ints(out)
+	return out
+}
+
+func pickerIdentity() string {
+	if id := os.Getenv("PICKER_ID"); id != "" {
+		return id
+	}
+	if host, err := os.Hostname(); err == nil {
+		return host
+	}
+	return "picker-unknown"
+}
+
+// register publishes /scheduler/pickers/<id> under a session lease so the
+// key disappears (and the coordinator rebalances) if this process dies.
+func register(ctx context.Context, pickerID string) (*concurrency.Session, error) {
+	session, err := concurrency.NewSession(etcdClient.Client, concurrency.WithTTL(5))
+	if err != nil {
+		return nil, err
+	}
+
+	registeredAt := time.Now().Format(time.RFC3339)
+	if _, err := etcdClient.Client.Put(ctx, sharding.PickerKey(pickerID), registeredAt, clientv3.WithLease(session.Lease())); err != nil {
+		session.Close()
+		return nil, err
+	}
+	log.Printf("Registered as picker %s", pickerID)
+	return session, nil
+}
+
+// watchAssignments keeps ownership in sync with /scheduler/assignments/<id>.
+func watchAssignments(ctx context.Context, pickerID string, ownership *shardOwnership) {
+	key := sharding.AssignmentKey(pickerID)
+
+	for ctx.Err() == nil {
+		resp, err := etcdClient.Client.Get(ctx, key)
+		if err != nil {
+			log.Printf("Failed to read assignment: %v", err)
+			time.Sleep(1 * time.Second)
+			continue
+		}
+		if len(resp.Kvs) == 0 {
+			ownership.Set(nil)
+		} else {
+			applyAssignment(ownership, resp.Kvs[0].Value)
+		}
+
+		watchCh := etcdClient.Client.Watch(ctx, key, clientv3.WithRev(resp.Header.Revision+1))
+		for wresp := range watchCh {
+			if err := wresp.Err(); err != nil {
+				log.Printf("Assignment watch error: %v", err)
+				break
+			}
+			for _, ev := range wresp.Events {
+				if ev.Type == clientv3.EventTypeDelete {
+					log.Println("Assignment removed, releasing all shards")
+					ownership.Set(nil)
+					continue
+				}
+				applyAssignment(ownership, ev.Kv.Value)
+			}
+		}
+		// Watch closed (compaction, connection loss); re-read and resume.
+	}
+}
+
+func applyAssignment(ownership *shardOwnership, value []byte) {
+	shards, err := sharding.Decode(value)
+	if err != nil {
+		// Fail closed: scanning shards we might not own risks double dispatch.
+		log.Printf("Malformed assignment, releasing all shards: %v", err)
+		ownership.Set(nil)
+		return
+	}
+	ownership.Set(shards)
+	log.Printf("Assignment updated: now owning %d shards", len(shards))
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// Shards scanned in parallel per tick.
+var scanConcurrency = intFromEnv("PICKER_SCAN_CONCURRENCY", 16)
+
+// Per-shard parallelism for the jobs lookup and claim of due rows.
+var fetchConcurrency = intFromEnv("PICKER_FETCH_CONCURRENCY", 16)
+
+func intFromEnv(name string, def int) int {
+	if v := os.Getenv(name); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("Invalid %s %q, using %d", name, v, def)
+	}
+	return def
+}
+
+// forEachLimit calls fn(i) for i in [0, n) on at most limit goroutines and
+// returns once all calls have finished.
+func forEachLimit(n, limit int, fn func(i int)) {
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			fn(i)
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"distributed_job_scheduler/pkg/infra"
+	"distributed_job_scheduler/pkg/observability"
+	"distributed_job_scheduler/pkg/outbox"
+	"distributed_job_scheduler/pkg/shutdown"
+)
+
+var (
+	scyllaClient  *infra.ScyllaClient
+	kafkaProducer *infra.KafkaProducer
+)
+
+var (
+	// How often every outbox bucket is swept.
+	pollInterval = durationFromEnv("OUTBOX_POLL_INTERVAL", 1*time.Second)
+	// Entries younger than this are left to the inline publish in ingestion.
+	minAge = durationFromEnv("OUTBOX_MIN_AGE", 10*time.Second)
+	// After this long, or maxAttempts failed publishes, the job is marked FAILED.
+	maxAge = durationFromEnv("OUTBOX_MAX_AGE", 1*time.Hour)
+)
+
+const maxAttempts = 20
+
+func main() {
+	log.Println("Starting Relay Service...")
+
+	ctx, stop := shutdown.NotifyContext()
+	defer stop()
+	grace := shutdown.GracePeriod()
+
+	metricshttp := http.NewServeMux()
+	metricshttp.Handle("/metrics", promhttp.Handler())
+	log.Println("Metrics endpoint listening on :8084")
+	metricsDone := shutdown.ListenAndServe(ctx, &http.Server{Addr: ":8084", Handler: metricshttp}, grace)
+
+	initInfra()
+	defer closeInfra()
+
+	log.Printf("Relay Service started. Sweeping %d outbox buckets every %v", outbox.NumBuckets, pollInterval)
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			<-metricsDone
+			log.Println("Relay Service stopped")
+			return
+		case <-ticker.C:
+			sweep(ctx)
+		}
+	}
+}
+
+func initInfra() {
+	var err error
+
+	// Scylla
+	scyllaHosts := strings.Split(os.Getenv("SCYLLA_HOSTS"), ",")
+	if len(scyllaHosts) == 0 || scyllaHosts[0] == "" {
+		scyllaHosts = []string{"scheduler-scylla"}
+	}
+	scyllaClient, err = infra.NewScyllaClient(scyllaHosts, "scheduler")
+	if err != nil {
+		log.Fatalf("Failed to connect to Scylla: %v", err)
+	}
+	log.Println("Connected to ScyllaDB")
+
+	// Kafka
+	kafkaBrokers := os.Getenv("KAFKA_BROKERS")
+	if kafkaBrokers == "" {
+		kafkaBrokers = "scheduler-kafka:29092"
+	}
+	kafkaProducer, err = infra.NewKafkaProducer(kafkaBrokers, "job-submissions")
+	if err != nil {
+		log.Fatalf("Failed to connect to Kafka: %v", err)
+	}
+	log.Println("Connected to Kafka")
+}
+
+func closeInfra() {
+	if scyllaClient != nil {
+		scyllaClient.Close()
+	}
+	if kafkaProducer != nil {
+		kafkaProducer.Close()
+	}
+}
+
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	if v := os.Getenv(name); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("Invalid %s %q, using %v", name, v, def)
+	}
+	return def
+}
+
+// sweep publishes every PENDING entry old enough to have missed its inline
+// publish. Publishing can duplicate an event the ingestion service also sent
+// (or one whose delete failed), and the writer then queues the job again,
+// possibly after the picker already dispatched and deleted its row. The
+// picker drops such rows: it only dispatches the job's current next_fire_at,
+// once (see recordDispatch in cmd/picker).
+func sweep(ctx context.Context) {
+	cutoff := time.Now().Add(-minAge)
+	pending := 0
+	for bucket := 0; bucket < outbox.NumBuckets; bucket++ {
+		if ctx.Err() != nil {
+			return
+		}
+		n, err := relayBucket(bucket, cutoff)
+		if err != nil {
+			log.Printf("Failed to sweep outbox bucket %d: %v", bucket, err)
+		}
+		pending += n
+	}
+	observability.OutboxPendingEntries.Set(float64(pending))
+}
+
+func relayBucket(bucket int, cutoff time.Time) (int, error) {
+	query := `SELECT created_at, job_id, event, attempts, last_error FROM submission_outbox WHERE bucket = ? AND created_at <= ?`
+	iter := scyllaClient.Session.Query(query, bucket, cutoff).Iter()
+
+	var entries []outbox.Entry
+	var createdAt time.Time
+	var jobID gocql.UUID
+	var event, lastError string
+	var attempts int
+	for iter.Scan(&createdAt, &jobID, &event, &attempts, &lastError) {
+		entries = append(entries, outbox.Entry{
+			Bucket:    bucket,
+			CreatedAt: createdAt,
+			JobID:     jobID.String(),
+			Event:     []byte(event),
+			Attempts:  attempts,
+			LastError: lastError,
+		})
+	}
+	if err := iter.Close(); err != nil {
+		return 0, err
+	}
+
+	for _, e := range entries {
+		relay(e)
+	}
+	return len(entries), nil
+}
+
+func relay(e outbox.Entry) {
+	if e.Attempts >= maxAttempts || time.Since(e.CreatedAt) > maxAge {
+		giveUp(e)
+		return
+	}
+
+	if err := kafkaProducer.Publish(e.JobID, e.Event); err != nil {
+		log.Printf("Relay publish failed for job %s (attempt %d): %v", e.JobID, e.Attempts+1, err)
+		observability.OutboxPublishErrors.Inc()
+		if err := outbox.RecordAttempt(scyllaClient.Session, e, err); err != nil {
+			log.Printf("Failed to record outbox attempt for job %s: %v", e.JobID, err)
+		}
+		return
+	}
+
+	if err := outbox.Delete(scyllaClient.Session, e); err != nil {
+		log.Printf("Failed to delete outbox entry of job %s: %v", e.JobID, err)
+		return
+	}
+	observability.OutboxPublishedTotal.WithLabelValues("relay").Inc()
+	log.Printf("Relayed submission of job %s (created %s)", e.JobID, e.CreatedAt.Format(time.RFC3339))
+}
+
+// giveUp marks a job that could never be handed to the writer as FAILED, so
+// it is visibly broken instead of PENDING forever with no job_queue row.
+func giveUp(e outbox.Entry) {
+	log.Printf("Giving up on submission of job %s after %d attempt(s): %s", e.JobID, e.Attempts, e.LastError)
+
+	var userID string
+	var createdAt time.Time
+	err := scyllaClient.Session.Query(`SELECT user_id, created_at FROM jobs WHERE job_id = ?`, e.JobID).Scan(&userID, &createdAt)
+	if err != nil && err != gocql.ErrNotFound {
+		log.Printf("Failed to load job %s: %v", e.JobID, err)
+		return
+	}
+
+	if err == nil {
+		query := `UPDATE jobs SET status = ?, updated_at = ? WHERE job_id = ? IF status = ?`
+		applied, err := scyllaClient.Session.Query(query, "FAILED", time.Now(), e.JobID, "PENDING").MapScanCAS(map[string]interface{}{})
+		if err != nil {
+			log.Printf("Failed to mark job %s as FAILED: %v", e.JobID, err)
+			return
+		}
+		// Not applied: the job was cancelled or paused meanwhile; leave it be
+		if applied && userID != "" {
+			userQuery := `UPDATE user_jobs SET status = ? WHERE user_id = ? AND created_at = ? AND job_id = ?`
+			if err := scyllaClient.Session.Query(userQuery, "FAILED", userID, createdAt, e.JobID).Exec(); err != nil {
+				log.Printf("Failed to update user_jobs for job %s (non-fatal): %v", e.JobID, err)
+			}
+		}
+	}
+
+	if err := outbox.Delete(scyllaClient.Session, e); err != nil {
+		log.Printf("Failed to delete outbox entry of job %s: %v", e.JobID, err)
+		return
+	}
+	observability.OutboxFailedTotal.Inc()
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"distributed_job_scheduler/pkg/infra"
+)
+
+var errJobCancelled = errors.New("job cancelled by user")
+
+// How often a running job re-checks jobs.status, as a fallback for a missed
+// pub/sub notification (e.g. the Redis subscription was reconnecting).
+const cancelPollInterval = 10 * time.Second
+
+// runRegistry tracks the cancel functions of runs executing on this worker.
+type runRegistry struct {
+	mu   sync.Mutex
+	runs map[string]map[string]context.CancelCauseFunc // job_id -> run_id -> cancel
+}
+
+var runningJobs = &runRegistry{runs: map[string]map[string]context.CancelCauseFunc{}}
+
+func (r *runRegistry) track(jobID, runID string, cancel context.CancelCauseFunc) func() {
+	r.mu.Lock()
+	if r.runs[jobID] == nil {
+		r.runs[jobID] = map[string]context.CancelCauseFunc{}
+	}
+	r.runs[jobID][runID] = cancel
+	r.mu.Unlock()
+
+	return func() {
+		r.mu.Lock()
+		delete(r.runs[jobID], runID)
+		if len(r.runs[jobID]) == 0 {
+			delete(r.runs, jobID)
+		}
+		r.mu.Unlock()
+	}
+}
+
+func (r *runRegistry) cancelJob(jobID string, cause error) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, cancel := range r.runs[jobID] {
+		cancel(cause)
+	}
+	return len(r.runs[jobID])
+}
+
+// subscribeCancellations stops local runs of any job cancelled through the API.
+func subscribeCancellations(ctx context.Context) {
+	sub := redisClient.Client.Subscribe(ctx, infra.JobCancellationChannel)
+	defer sub.Close()
+
+	for msg := range sub.Channel() {
+		if n := runningJobs.cancelJob(msg.Payload, errJobCancelled); n > 0 {
+			log.Printf("Cancellation received for job %s, stopping %d run(s)", msg.Payload, n)
+		}
+	}
+}
+
+// watchForCancellation polls jobs.status while a run executes.
+func watchForCancellation(ctx context.Context, jobID string, cancel context.CancelCauseFunc) {
+	ticker := time.NewTicker(cancelPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if status, err := currentJobStatus(jobID); err == nil && status == "CANCELLED" {
+				log.Printf("Job %s cancelled (detected by poll), stopping run", jobID)
+				cancel(errJobCancelled)
+				return
+			}
+		}
+	}
+}
+
+func currentJobStatus(jobID string) (string, error) {
+	var status string
+	err := scyllaClient.Session.Query(`SELECT status FROM jobs WHERE job_id = ?`, jobID).Scan(&status)
+	return status, err
+}
+
+// releaseSkippedRun undoes the dispatch of a run that was skipped because
+// its job is paused. The picker dispatches an occurrence only once (see
+// recordDispatch in cmd/picker), so without this the row resume queues at
+// the same next_fire_at would be dropped. If the job was resumed while the
+// run sat in SQS, that row may already be gone, so it is queued again here.
+func releaseSkippedRun(event JobExecutionEvent) {
+	query := `UPDATE jobs SET dispatched_fire_at = null, dispatched_run_id = null WHERE job_id = ? IF dispatched_run_id = ?`
+	applied, err := scyllaClient.Session.Query(query, event.JobID, event.RunID).MapScanCAS(map[string]interface{}{})
+	if err != nil {
+		log.Printf("Failed to release skipped run %s of job %s: %v", event.RunID, event.JobID, err)
+		return
+	}
+	if !applied {
+		return
+	}
+
+	var status string
+	var nextFireAt time.Time
+	var shardID int
+	err = scyllaClient.Session.Query(`SELECT status, next_fire_at, shard_id FROM jobs WHERE job_id = ?`, event.JobID).Scan(&status, &nextFireAt, &shardID)
+	if err != nil {
+		log.Printf("Failed to reload job %s: %v", event.JobID, err)
+		return
+	}
+	if status != "PENDING" && status != "RETRYING" {
+		return
+	}
+	queueQuery := `INSERT INTO job_queue (shard_id, next_fire_at, job_id, status) VALUES (?, ?, ?, ?)`
+	if err := scyllaClient.Session.Query(queueQuery, shardID, nextFireAt, event.JobID, "PENDING").Exec(); err != nil {
+		log.Printf("Failed to re-queue resumed job %s: %v", event.JobID, err)
+	}
+}
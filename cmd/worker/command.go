@@ -0,0 +1,31 @@
+package main
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// Timeout applied when a job doesn't set timeout_seconds.
+var defaultJobTimeout = durationFromEnv("DEFAULT_JOB_TIMEOUT", 1*time.Hour)
+
+// How long a process group gets between SIGTERM and SIGKILL.
+var killGracePeriod = durationFromEnv("KILL_GRACE_PERIOD", 5*time.Second)
+
+func durationFromEnv(name string, def time.Duration) time.Duration {
+	if v := os.Getenv(name); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		log.Printf("Invalid %s %q, using %v", name, v, def)
+	}
+	return def
+}
+
+// runTimeout resolves the effective deadline for a run.
+func runTimeout(timeoutSeconds int) time.Duration {
+	if timeoutSeconds > 0 {
+		return time.Duration(timeoutSeconds) * time.Second
+	}
+	return defaultJobTimeout
+}
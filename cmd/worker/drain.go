@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+var errWorkerShutdown = errors.New("worker shutting down")
+
+// workerGracePeriod is the worker's default stop budget, under the 60s
+// stop_grace_period it gets in docker-compose.yml. It is longer than other
+// services' so running jobs still get 35s once the kill grace period and
+// shutdownRecordAllowance are taken out.
+const workerGracePeriod = 55 * time.Second
+
+// shutdownRecordAllowance is kept out of the drain grace period for killed
+// runs to write job_runs, upload their output (see shutdownUploadTimeout),
+// close their live log and hand their messages back.
+const shutdownRecordAllowance = 15 * time.Second
+
+// abortRuns is cancelled (with errWorkerShutdown) when the drain grace period
+// runs out; every executing run is stopped and handed back to SQS.
+var abortRuns, abortAllRuns = context.WithCancelCause(context.Background())
+
+// drain waits for in-flight jobs after intake has stopped. grace is the
+// whole stop budget: jobs get what is left of it after the kill grace period
+// and shutdownRecordAllowance. Jobs still running then are killed;
+// processMessage records what they produced so far and resets their message
+// visibility so another worker picks them up immediately instead of after
+// the visibility timeout.
+func drain(pool *executorPool, grace time.Duration) {
+	jobWait := grace - killGracePeriod - shutdownRecordAllowance
+	if jobWait < 0 {
+		jobWait = 0
+	}
+
+	done := make(chan struct{})
+	go func() {
+		pool.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		log.Println("All in-flight jobs finished")
+		return
+	case <-time.After(jobWait):
+	}
+
+	log.Printf("Job wait (%v of the %v grace period) expired, handing unfinished jobs back to SQS", jobWait, grace)
+	abortAllRuns(errWorkerShutdown)
+
+	// Killed runs still need to record partial output and release their messages
+	select {
+	case <-done:
+	case <-time.After(killGracePeriod + shutdownRecordAllowance):
+		log.Println("Timed out waiting for interrupted jobs to release their messages")
+	}
+}
+
+// handBack makes msg visible again right away.
+func handBack(msg types.Message) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := sqsClient.ChangeMessageVisibility(ctx, *msg.ReceiptHandle, 0); err != nil {
+		log.Printf("Failed to reset visibility of interrupted message: %v", err)
+	}
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"time"
+
+	"distributed_job_scheduler/pkg/observability"
+)
+
+// runTimeline is when a run was meant to fire, when the picker sent it to
+// SQS and when this worker started executing it.
+type runTimeline struct {
+	ScheduledFor time.Time
+	DispatchedAt time.Time
+	StartedAt    time.Time
+}
+
+// observeTimeline parses the picker's timestamps and records the scheduling
+// latency histograms. Events from older pickers lack them; those
+// stages are left zero (null in job_runs) and not observed.
+func observeTimeline(event JobExecutionEvent, startedAt time.Time) runTimeline {
+	tl := runTimeline{StartedAt: startedAt}
+	tl.ScheduledFor, _ = time.Parse(time.RFC3339Nano, event.ScheduledFor)
+	tl.DispatchedAt, _ = time.Parse(time.RFC3339Nano, event.DispatchedAt)
+
+	if !tl.ScheduledFor.IsZero() && !tl.DispatchedAt.IsZero() {
+		observability.ScheduleToDispatchSeconds.WithLabelValues(event.ProjectID).Observe(tl.DispatchedAt.Sub(tl.ScheduledFor).Seconds())
+	}
+	if !tl.DispatchedAt.IsZero() {
+		observability.DispatchToStartSeconds.WithLabelValues(event.ProjectID).Observe(startedAt.Sub(tl.DispatchedAt).Seconds())
+	}
+	return tl
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"distributed_job_scheduler/pkg/infra"
+)
+
+// Live run logs go to a Redis stream (infra.RunLogStream) in small batches
+// while the run executes; ingestion serves them over SSE. The stream expires
+// runLogRetention after its last entry; job_runs and S3 keep the output.
+const (
+	runLogRetention     = 24 * time.Hour
+	runLogMaxEntries    = 100000 // per run, trimmed approximately
+	runLogFlushInterval = 250 * time.Millisecond
+	runLogMaxPending    = 10000 // lines held while Redis is slow; later ones are dropped
+	runLogWriteTimeout  = 5 * time.Second
+)
+
+// runLog is the executor.LogSink of one run.
+type runLog struct {
+	jobID, runID string
+	key          string
+
+	mu      sync.Mutex
+	pending [][]string
+	dropped int
+
+	stop    chan struct{}
+	stopped chan struct{}
+}
+
+// startRunLog registers the run as live and opens its log stream.
+func startRunLog(event JobExecutionEvent, startedAt time.Time) *runLog {
+	l := &runLog{
+		jobID:   event.JobID,
+		runID:   event.RunID,
+		key:     infra.RunLogStream(event.JobID, event.RunID),
+		stop:    make(chan struct{}),
+		stopped: make(chan struct{}),
+	}
+
+	workerID, _ := os.Hostname()
+	attempt := event.RetryCount + 1
+	live, _ := json.Marshal(infra.LiveRun{WorkerID: workerID, Attempt: attempt, StartedAt: startedAt})
+	ctx, cancel := context.WithTimeout(context.Background(), runLogWriteTimeout)
+	defer cancel()
+	pipe := redisClient.Client.Pipeline()
+	pipe.HSet(ctx, infra.LiveRunsKey(event.JobID), event.RunID, live)
+	// Bounds how long a crashed worker's entry can linger
+	pipe.Expire(ctx, infra.LiveRunsKey(event.JobID), runLogRetention)
+	l.xadd(ctx, pipe, []string{"type", "start", "ts", startedAt.Format(time.RFC3339Nano),
+		"attempt", strconv.Itoa(attempt), "worker_id", workerID})
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to open live log of run %s: %v", event.RunID, err)
+	}
+
+	go l.flushLoop()
+	return l
+}
+
+// WriteLine implements executor.LogSink. It only buffers; flushLoop writes.
+func (l *runLog) WriteLine(stream string, ts time.Time, line string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.pending) >= runLogMaxPending {
+		l.dropped++
+		return
+	}
+	l.pending = append(l.pending, []string{"type", stream, "ts", ts.Format(time.RFC3339Nano), "line", line})
+}
+
+func (l *runLog) flushLoop() {
+	defer close(l.stopped)
+	ticker := time.NewTicker(runLogFlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			l.flush()
+		case <-l.stop:
+			l.flush()
+			return
+		}
+	}
+}
+
+func (l *runLog) flush() {
+	l.mu.Lock()
+	batch := l.pending
+	l.pending = nil
+	l.mu.Unlock()
+	if len(batch) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), runLogWriteTimeout)
+	defer cancel()
+	pipe := redisClient.Client.Pipeline()
+	for _, entry := range batch {
+		l.xadd(ctx, pipe, entry)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to write %d live log lines of run %s: %v", len(batch), l.runID, err)
+	}
+}
+
+func (l *runLog) xadd(ctx context.Context, pipe redis.Pipeliner, values []string) {
+	pipe.XAdd(ctx, &redis.XAddArgs{Stream: l.key, MaxLen: runLogMaxEntries, Approx: true, Values: values})
+	pipe.Expire(ctx, l.key, runLogRetention)
+}
+
+// finish flushes what is left and closes the stream with the run's status.
+// An interrupted run is re-delivered with the same run_id and continues the
+// stream, so followers are told but not disconnected.
+func (l *runLog) finish(status string) {
+	close(l.stop)
+	<-l.stopped
+
+	entry := []string{"type", "end", "ts", time.Now().Format(time.RFC3339Nano), "status", status}
+	if status == "INTERRUPTED" {
+		entry[1] = "interrupted"
+	}
+	l.mu.Lock()
+	if l.dropped > 0 {
+		entry = append(entry, "dropped", strconv.Itoa(l.dropped))
+	}
+	l.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), runLogWriteTimeout)
+	defer cancel()
+	pipe := redisClient.Client.Pipeline()
+	l.xadd(ctx, pipe, entry)
+	pipe.HDel(ctx, infra.LiveRunsKey(l.jobID), l.runID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("Failed to close live log of run %s: %v", l.runID, err)
+	}
+}
@@ -3,22 +3,24 @@ package main
 import (
     "context"
     "encoding/json"
+    "errors"
     "fmt"
     "io"
     "log"
     "math/rand"
     "net/http"
     "os"
-    "os/exec"
     "strings"
     "time"
 
+    "distributed_job_scheduler/pkg/executor"
     "distributed_job_scheduler/pkg/infra"
     "distributed_job_scheduler/pkg/observability"
+    "distributed_job_scheduler/pkg/schedule"
+    "distributed_job_scheduler/pkg/shutdown"
     "github.com/aws/aws-sdk-go-v2/aws"
     "github.com/aws/aws-sdk-go-v2/service/s3"
     "github.com/aws/aws-sdk-go-v2/service/sqs/types"
-    "github.com/robfig/cron/v3"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
@@ -30,35 +32,58 @@ type JobExecutionEvent struct {
     ProjectID  string `json:"project_id"`
     UserID     string `json:"user_id"`
     ExecutedAt string `json:"executed_at"`
+    ScheduledFor string `json:"scheduled_for"` // job_queue next_fire_at the run was dispatched for
+    DispatchedAt string `json:"dispatched_at"`
+    OccurrenceAt string `json:"occurrence_at"` // cron occurrence this run belongs to (kept across retries)
+    MisfirePolicy string `json:"misfire_policy"`
+    MaxCatchup int `json:"max_catchup"`
+    Timezone string `json:"timezone"` // empty for jobs created before timezone support (UTC)
     CronSchedule string `json:"cron_schedule"`
+    JobType    string `json:"job_type"` // empty for jobs stored before job types existed
+    MaxRetries int    `json:"max_retries"`
+    RetryCount int    `json:"retry_count"`
+    TimeoutSeconds int `json:"timeout_seconds"`
 }
 
 var (
     scyllaClient *infra.ScyllaClient
     sqsClient    *infra.SQSClient
     s3Client     *infra.S3Client
-    parser        = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+    redisClient  *infra.RedisClient
 )
 
 func main() {
     log.Println("Starting Worker Service...")
 
+    ctx, stop := shutdown.NotifyContext()
+    defer stop()
+    grace := shutdown.GracePeriodOr(workerGracePeriod)
+    executor.KillGracePeriod = killGracePeriod
+    executor.CommandSandbox = loadSandbox()
+    executor.CommandProjects = executor.ParseProjects(os.Getenv("CMD_ALLOWED_PROJECTS"))
+    if len(executor.CommandProjects) == 0 {
+        log.Println("CMD_ALLOWED_PROJECTS is not set: cmd jobs are refused for every project (\"*\" allows all)")
+    }
+
     // Init Metrics
-	go func() {
-		metricshttp := http.NewServeMux()
-		metricshttp.Handle("/metrics", promhttp.Handler())
-		log.Println("Metrics endpoint listening on :8083")
-		if err := http.ListenAndServe(":8083", metricshttp); err != nil {
-			log.Printf("Metrics server failed: %v", err)
-		}
-	}()
+	metricshttp := http.NewServeMux()
+	metricshttp.Handle("/metrics", promhttp.Handler())
+	log.Println("Metrics endpoint listening on :8083")
+	metricsDone := shutdown.ListenAndServe(ctx, &http.Server{Addr: ":8083", Handler: metricshttp}, grace)
 
     initInfra()
     defer closeInfra()
 
     log.Println("Worker Service started. Polling SQS...")
     
-    runLoop()
+    pool := newExecutorPool(workerConcurrency)
+    runLoop(ctx, pool)
+
+    // SIGTERM: intake has stopped; finish or hand back what's running
+    log.Printf("Shutdown signal received, draining in-flight jobs (grace %v)...", grace)
+    drain(pool, grace)
+    <-metricsDone
+    log.Println("Worker Service stopped")
 }
 
 func initInfra() {
@@ -91,31 +116,58 @@ func initInfra() {
     if err != nil {
         log.Fatalf("Failed to connect to S3: %v", err)
     }
+    if err := s3Client.EnsureBucket(outputBucket); err != nil {
+        log.Printf("Failed to ensure S3 bucket %s: %v", outputBucket, err)
+    }
     log.Println("Connected to S3")
+
+    // Redis (job cancellation notifications)
+    redisAddr := os.Getenv("REDIS_ADDR")
+    if redisAddr == "" { redisAddr = "scheduler-redis:6379" }
+    redisClient, err = infra.NewRedisClient(redisAddr, "", 0)
+    if err != nil {
+        log.Fatalf("Failed to connect to Redis: %v", err)
+    }
+    log.Println("Connected to Redis")
 }
 
 func closeInfra() {
     if scyllaClient != nil { scyllaClient.Close() }
+    if redisClient != nil { redisClient.Close() }
     // SQS/S3 clients usually don't need close
 }
 
-func runLoop() {
-    ctx := context.TODO() // In real app, use cancellable context
+// runLoop receives messages until ctx is cancelled. Jobs themselves run on
+// a context that outlives ctx so a shutdown signal doesn't kill them outright.
+func runLoop(ctx context.Context, pool *executorPool) {
+    // Subscription lives until the process exits so draining jobs can still be cancelled
+    go subscribeCancellations(abortRuns)
+
+    log.Printf("Executing up to %d jobs concurrently", workerConcurrency)
+
     for {
+        // Only receive as many messages as we have free slots
+        free := pool.acquire(ctx, maxReceiveBatch)
+        if free == 0 {
+            return
+        }
+
         // Long polling
-        resp, err := sqsClient.ReceiveMessages(ctx, 10, 5) // max 10 messages, 5s wait
+        resp, err := sqsClient.ReceiveMessages(ctx, int32(free), 5) // 5s wait
+        if ctx.Err() != nil {
+            pool.release(free)
+            return
+        }
         if err != nil {
             log.Printf("SQS Receive error: %v", err)
+            pool.release(free)
             time.Sleep(1 * time.Second)
             continue
         }
 
-        if len(resp.Messages) == 0 {
-            continue
-        }
-
+        pool.release(free - len(resp.Messages))
         for _, msg := range resp.Messages {
-            processMessage(ctx, msg)
+            pool.run(context.Background(), msg)
         }
     }
 }
@@ -132,7 +184,31 @@ func processMessage(ctx context.Context, msg types.Message) {
         return
     }
     
+    // A picker that died before deleting its job_queue row re-dispatches it
+    // with the same run_id; don't execute a run that already finished.
+    if status, err := recordedRunStatus(event.JobID, event.RunID); err == nil && status != "INTERRUPTED" {
+        log.Printf("Skipping run %s of job %s: already recorded as %s", event.RunID, event.JobID, status)
+        if err := sqsClient.DeleteMessage(ctx, *msg.ReceiptHandle); err != nil {
+            log.Printf("Failed to delete message %s: %v", event.JobID, err)
+        }
+        return
+    }
+
+    // Skip runs whose job was cancelled or paused after dispatch.
+    // A paused job is re-queued from jobs.next_fire_at on resume.
+    if status, err := currentJobStatus(event.JobID); err == nil && (status == "CANCELLED" || status == "PAUSED") {
+        log.Printf("Skipping run %s: job %s is %s", event.RunID, event.JobID, status)
+        if status == "PAUSED" {
+            releaseSkippedRun(event)
+        }
+        if err := sqsClient.DeleteMessage(ctx, *msg.ReceiptHandle); err != nil {
+            log.Printf("Failed to delete message %s: %v", event.JobID, err)
+        }
+        return
+    }
+
     startExec := time.Now()
+    timeline := observeTimeline(event, startExec)
     defer func() {
         observability.JobExecutionDuration.Observe(time.Since(startExec).Seconds())
     }()
@@ -167,48 +243,75 @@ func processMessage(ctx context.Context, msg types.Message) {
     var jobOutput string
     var jobStatus string
     var errorMessage string
+    var killSignal string
+    var httpStatus int
+    var exitCode *int
+    var termSignal string
+    var responseHeaders map[string]string
+
+    // Cancellable execution context (see cancel.go), bounded by the run timeout
+    execCtx, cancelExec := context.WithCancelCause(ctx)
+    defer cancelExec(nil)
+    untrack := runningJobs.track(event.JobID, event.RunID, cancelExec)
+    go watchForCancellation(execCtx, event.JobID, cancelExec)
+    stopAbort := context.AfterFunc(abortRuns, func() { cancelExec(errWorkerShutdown) })
+    defer stopAbort()
+
+    timeout := runTimeout(event.TimeoutSeconds)
+    runCtx, cancelRun := context.WithTimeout(execCtx, timeout)
+    defer cancelRun()
+
+    // Output is streamed to Redis while the run executes (see logs.go)
+    runLogs := startRunLog(event, startExec)
+
+    // Hand the payload to the executor registered for its job type
+    jobType, payload, err := resolveJobType(event)
+    if err != nil {
+        log.Printf("Cannot run job %s: %v", event.JobID, err)
+        jobStatus = "FAILED"
+        errorMessage = err.Error()
+    } else {
+        runner, _ := executor.Lookup(jobType)
+        log.Printf("Running %s executor for job %s", jobType, event.JobID)
+
+        result := runner.Execute(runCtx, executor.Request{JobID: event.JobID, RunID: event.RunID, ProjectID: event.ProjectID, Payload: payload, Logs: runLogs})
+        killSignal = result.Signal
+        exitCode, termSignal = result.ExitCode, result.TermSignal
+        jobOutput = string(result.Output) // May contain stderr
+        if result.Response != nil {
+            httpStatus = result.Response.StatusCode
+            responseHeaders = result.Response.Headers
+        }
 
-    // Command execution
-    if strings.HasPrefix(event.Payload, "cmd:") {
-        cmdStr := strings.TrimPrefix(event.Payload, "cmd:")
-        log.Printf("Executing command: %s", cmdStr)
-        
-        cmd := exec.Command("sh", "-c", cmdStr)
-        output, err := cmd.CombinedOutput()
-        
-        if err != nil {
-            log.Printf("Command execution failed: %v", err)
+        if result.Err != nil {
+            log.Printf("Job %s (%s) failed: %v", event.JobID, jobType, result.Err)
             jobStatus = "FAILED"
-            errorMessage = fmt.Sprintf("Command failed: %v", err)
-            jobOutput = string(output) // May contain stderr
-            observability.JobsExecutedTotal.WithLabelValues("failed").Inc()
+            errorMessage = result.Err.Error()
         } else {
             jobStatus = "COMPLETED"
-            jobOutput = string(output)
-            log.Printf("Command executed successfully. Output length: %d bytes", len(output))
-            observability.JobsExecutedTotal.WithLabelValues("success").Inc()
+            log.Printf("Job %s (%s) succeeded. Output length: %d bytes", event.JobID, jobType, len(result.Output))
         }
-    } else if strings.HasPrefix(event.Payload, "sleep:") {
-        // Sleep simulation
-        durationStr := strings.TrimPrefix(event.Payload, "sleep:")
-        duration, err := time.ParseDuration(durationStr)
-        if err == nil {
-            log.Printf("Sleeping for %v as requested...", duration)
-            time.Sleep(duration)
-        } else {
-            log.Printf("Invalid sleep duration: %v, using default", err)
-            time.Sleep(50 * time.Millisecond)
+    }
+
+    untrack()
+    elapsed := time.Since(startExec)
+    if errors.Is(context.Cause(execCtx), errWorkerShutdown) {
+        log.Printf("Run %s of job %s interrupted by shutdown", event.RunID, event.JobID)
+        jobStatus = "INTERRUPTED"
+        errorMessage = "Worker shut down before the run finished; handed back to the queue"
+    } else if errors.Is(context.Cause(execCtx), errJobCancelled) {
+        log.Printf("Run %s of job %s was cancelled while executing", event.RunID, event.JobID)
+        jobStatus = "CANCELLED"
+        errorMessage = "Cancelled while running"
+    } else if errors.Is(runCtx.Err(), context.DeadlineExceeded) {
+        log.Printf("Run %s of job %s timed out after %v", event.RunID, event.JobID, timeout)
+        jobStatus = "TIMED_OUT"
+        errorMessage = fmt.Sprintf("Timed out after %v (elapsed %v)", timeout, elapsed.Round(time.Millisecond))
+        if killSignal != "" {
+            errorMessage += ", process group sent " + killSignal
         }
-        jobStatus = "COMPLETED"
-        jobOutput = "Success: " + event.Payload
-        observability.JobsExecutedTotal.WithLabelValues("success").Inc()
-    } else {
-        // Default simulation
-        time.Sleep(50 * time.Millisecond)
-        jobStatus = "COMPLETED"
-        jobOutput = "Success: " + event.Payload
-        observability.JobsExecutedTotal.WithLabelValues("success").Inc()
     }
+    observability.JobsExecutedTotal.WithLabelValues(executionOutcome(jobStatus)).Inc()
 
     // Get Worker ID (Hostname)
     workerID, err := os.Hostname()
@@ -216,8 +319,11 @@ func processMessage(ctx context.Context, msg types.Message) {
         workerID = "unknown-worker"
     }
 
+    // Large output goes to S3; the row keeps a preview and the reference
+    output := storeOutput(event.JobID, event.RunID, []byte(jobOutput))
+
     // Record Run
-    query := `INSERT INTO job_runs (job_id, run_id, user_id, status, triggered_at, completed_at, output, worker_id, error_message) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+    query := `INSERT INTO job_runs (job_id, run_id, user_id, status, triggered_at, completed_at, output, output_ref, output_bytes, worker_id, error_message, attempt, duration_ms, kill_signal, scheduled_for, dispatched_at, started_at, http_status, response_headers, exit_code, term_signal) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
     
     now := time.Now()
     startedAt, _ := time.Parse(time.RFC3339, event.ExecutedAt)
@@ -229,9 +335,24 @@ func processMessage(ctx context.Context, msg types.Message) {
         jobStatus, 
         startedAt, 
         now, 
-        jobOutput,
+        output.Preview,
+        output.Ref,
+        output.Bytes,
         workerID,
-        errorMessage).Exec()
+        errorMessage,
+        event.RetryCount+1,
+        elapsed.Milliseconds(),
+        killSignal,
+        timeline.ScheduledFor,
+        timeline.DispatchedAt,
+        timeline.StartedAt,
+        httpStatus,
+        responseHeaders,
+        exitCode,
+        termSignal).Exec()
+
+    // Closed once the run is in job_runs, so followers can fetch it on "end"
+    runLogs.finish(jobStatus)
 
     if err != nil {
         log.Printf("Scylla write failed for run %s: %v", event.RunID, err)
@@ -242,6 +363,33 @@ func processMessage(ctx context.Context, msg types.Message) {
 
     log.Printf("Job %s Completed with status: %s", event.JobID, jobStatus)
 
+    // Interrupted runs go back to SQS as-is; the redelivery reuses this run_id
+    if jobStatus == "INTERRUPTED" {
+        handBack(msg)
+        return
+    }
+
+    // Cancelled runs are final: no retry, no reschedule, status already CANCELLED
+    if jobStatus == "CANCELLED" {
+        if err := sqsClient.DeleteMessage(ctx, *msg.ReceiptHandle); err != nil {
+            log.Printf("Failed to delete message %s: %v", event.JobID, err)
+        }
+        return
+    }
+
+    // Retry failed or timed-out runs until max_retries is exhausted
+    if (jobStatus == "FAILED" || jobStatus == "TIMED_OUT") && event.RetryCount < event.MaxRetries {
+        if err := scheduleRetry(event); err != nil {
+            log.Printf("Failed to schedule retry for job %s: %v", event.JobID, err)
+            // Leave the message so SQS redelivers it
+            return
+        }
+        if err := sqsClient.DeleteMessage(ctx, *msg.ReceiptHandle); err != nil {
+            log.Printf("Failed to delete message %s: %v", event.JobID, err)
+        }
+        return
+    }
+
     // Handle Recurring Jobs
     if event.CronSchedule != "" {
         handleReschedule(event)
@@ -256,13 +404,43 @@ func processMessage(ctx context.Context, msg types.Message) {
     }
 }
 
+// executionOutcome maps a run status to the jobs_executed_total label.
+func executionOutcome(status string) string {
+    switch status {
+    case "COMPLETED":
+        return "success"
+    case "CANCELLED":
+        return "cancelled"
+    case "TIMED_OUT":
+        return "timed_out"
+    case "INTERRUPTED":
+        return "interrupted"
+    default:
+        return "failed"
+    }
+}
+
 func updateJobStatus(jobID, userID, status string) {
-	// Update jobs table
-	updateJobQuery := `UPDATE jobs SET status = ? WHERE job_id = ?`
-	if err := scyllaClient.Session.Query(updateJobQuery, status, jobID).Exec(); err != nil {
+	// Update jobs table, unless the job was cancelled in the meantime
+	updateJobQuery := `UPDATE jobs SET status = ?, updated_at = ? WHERE job_id = ? IF status IN ('PENDING', 'RETRYING', 'PAUSED')`
+	previous := map[string]interface{}{}
+	applied, err := scyllaClient.Session.Query(updateJobQuery, status, time.Now(), jobID).MapScanCAS(previous)
+	if err != nil {
 		log.Printf("Failed to update job status for job %s: %v", jobID, err)
 		return
 	}
+	if !applied {
+		log.Printf("Not marking job %s %s: status is already %v", jobID, status, previous["status"])
+		return
+	}
+
+	updateUserJobStatus(jobID, userID, status)
+}
+
+func updateUserJobStatus(jobID, userID, status string) {
+	if userID == "" {
+		return
+	}
 
 	// user_jobs table has composite PRIMARY KEY (user_id, created_at, job_id)
 	// We need to get created_at first to update it
@@ -283,28 +461,116 @@ func updateJobStatus(jobID, userID, status string) {
 	log.Printf("Updated job %s status to %s", jobID, status)
 }
 
+// handleReschedule queues the occurrence after the one this run belonged to.
+// The next fire time is computed from that occurrence's scheduled time, not
+// from when the run finished, so long runs don't shift the schedule; missed
+// occurrences are handled per the job's misfire policy.
 func handleReschedule(event JobExecutionEvent) {
-    schedule, err := parser.Parse(event.CronSchedule)
+    sched, err := schedule.ParseIn(event.CronSchedule, event.Timezone)
     if err != nil {
-        log.Printf("Failed to parse cron schedule '%s' for job %s: %v", event.CronSchedule, event.JobID, err)
+        log.Printf("Failed to parse cron schedule '%s' (timezone %q) for job %s: %v", event.CronSchedule, event.Timezone, event.JobID, err)
         return
     }
 
-    nextFireAt := schedule.Next(time.Now())
+    now := time.Now()
+    prev := occurrenceOf(event, now)
+    nextFireAt, skipped := schedule.NextFire(sched, prev, now, event.MisfirePolicy, event.MaxCatchup)
     shardID := rand.Intn(1024) // Simple random sharding for now
 
+    if skipped > 0 {
+        log.Printf("Job %s missed %d occurrence(s) since %v; not running them (misfire_policy %q)", event.JobID, skipped, prev, event.MisfirePolicy)
+        observability.MisfiredOccurrencesTotal.WithLabelValues(misfireLabel(event.MisfirePolicy)).Add(float64(skipped))
+    }
     log.Printf("Rescheduling job %s to %v (Shard %d)", event.JobID, nextFireAt, shardID)
 
-    // 1. Update 'jobs' table with new next_fire_at
-    updateQuery := `UPDATE jobs SET next_fire_at = ?, shard_id = ?, status = 'PENDING' WHERE job_id = ?`
-    if err := scyllaClient.Session.Query(updateQuery, nextFireAt, shardID, event.JobID).Exec(); err != nil {
+    // 1. Update 'jobs' table with new next_fire_at (the next occurrence starts with a fresh retry budget)
+    enqueue, err := advanceJob(event.JobID, nextFireAt, shardID, 0, "PENDING", true)
+    if err != nil {
         log.Printf("Failed to update jobs table for rescheduling: %v", err)
         return // Retry logic would go here
     }
+    if !enqueue {
+        return
+    }
 
     // 2. Insert into 'job_queue'
-    queueQuery := `INSERT INTO job_queue (shard_id, next_fire_at, job_id) VALUES (?, ?, ?)`
-    if err := scyllaClient.Session.Query(queueQuery, shardID, nextFireAt, event.JobID).Exec(); err != nil {
+    queueQuery := `INSERT INTO job_queue (shard_id, next_fire_at, job_id, status) VALUES (?, ?, ?, ?)`
+    if err := scyllaClient.Session.Query(queueQuery, shardID, nextFireAt, event.JobID, "PENDING").Exec(); err != nil {
         log.Printf("Failed to enqueue rescheduled job: %v", err)
     }
+
+    // Clear the RETRYING marker left by a previous attempt of this occurrence
+    if event.RetryCount > 0 {
+        updateUserJobStatus(event.JobID, event.UserID, "PENDING")
+    }
+}
+
+// occurrenceOf returns the scheduled time of the occurrence event ran for.
+// Events from older pickers carry no occurrence_at; fall back to the queue
+// row's time and, failing that, to now (the old behaviour).
+func occurrenceOf(event JobExecutionEvent, now time.Time) time.Time {
+    for _, ts := range []string{event.OccurrenceAt, event.ScheduledFor} {
+        if t, err := time.Parse(time.RFC3339Nano, ts); err == nil {
+            return t
+        }
+    }
+    return now
+}
+
+func misfireLabel(policy string) string {
+    if policy == "" {
+        return schedule.DefaultMisfirePolicy
+    }
+    return policy
+}
+
+// advanceJob moves an active job to its next fire time. If the job was paused
+// while running, only next_fire_at/retry_count are recorded (resume re-queues
+// from there); if it was cancelled nothing changes. newOccurrence is false for
+// retries, which keep occurrence_at pointing at the occurrence being retried.
+// It reports whether the caller should insert the job_queue row.
+func advanceJob(jobID string, nextFireAt time.Time, shardID, retryCount int, status string, newOccurrence bool) (bool, error) {
+    set := `next_fire_at = ?, shard_id = ?, retry_count = ?`
+    args := []interface{}{nextFireAt, shardID, retryCount}
+    if newOccurrence {
+        set += `, occurrence_at = ?`
+        args = append(args, nextFireAt)
+    }
+
+    query := `UPDATE jobs SET ` + set + `, status = ?, updated_at = ? WHERE job_id = ? IF status IN ('PENDING', 'RETRYING')`
+    previous := map[string]interface{}{}
+    applied, err := scyllaClient.Session.Query(query, append(args, status, time.Now(), jobID)...).MapScanCAS(previous)
+    if err != nil || applied {
+        return applied, err
+    }
+
+    switch previous["status"] {
+    case "PAUSED":
+        log.Printf("Job %s is paused; recording next fire %v without queueing", jobID, nextFireAt)
+        pausedQuery := `UPDATE jobs SET ` + set + `, updated_at = ? WHERE job_id = ? IF status = 'PAUSED'`
+        _, err := scyllaClient.Session.Query(pausedQuery, append(args, time.Now(), jobID)...).MapScanCAS(map[string]interface{}{})
+        return false, err
+    default:
+        log.Printf("Job %s is %v; not scheduling further runs", jobID, previous["status"])
+        return false, nil
+    }
+}
+
+func recordedRunStatus(jobID, runID string) (string, error) {
+    var status string
+    err := scyllaClient.Session.Query(`SELECT status FROM job_runs WHERE job_id = ? AND run_id = ?`, jobID, runID).Scan(&status)
+    return status, err
+}
+
+// resolveJobType picks the executor for event. Jobs stored before job_type
+// existed carry the type as a payload prefix; those without a known prefix
+// fail the run rather than reporting a success nothing produced.
+func resolveJobType(event JobExecutionEvent) (string, string, error) {
+    if event.JobType != "" {
+        if _, ok := executor.Lookup(event.JobType); !ok {
+            return "", "", fmt.Errorf("no executor for job_type %q on this worker", event.JobType)
+        }
+        return event.JobType, event.Payload, nil
+    }
+    return executor.Resolve("", event.Payload)
 }
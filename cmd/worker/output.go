@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"time"
+	"unicode/utf8"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+
+	"distributed_job_scheduler/pkg/observability"
+)
+
+// Run output larger than this goes to S3; job_runs.output keeps a preview of
+// this many bytes. Same 1KB threshold ingestion uses for payloads.
+const outputInlineLimit = 1024
+
+const outputBucket = "job-outputs"
+
+const outputUploadTimeout = 30 * time.Second
+
+// Once the drain has killed runs their uploads share shutdownRecordAllowance.
+const shutdownUploadTimeout = 5 * time.Second
+
+// storedOutput is what job_runs records about a run's output.
+type storedOutput struct {
+	Preview string // whole output, or its first outputInlineLimit bytes
+	Ref     string // "s3:outputs/{job_id}/{run_id}" when offloaded, else ""
+	Bytes   int64  // full size
+}
+
+// storeOutput offloads large output to S3. If the upload fails the row still
+// gets the preview, never the full output, so a chatty job can't write
+// megabytes into a Scylla cell.
+func storeOutput(jobID, runID string, output []byte) storedOutput {
+	stored := storedOutput{Bytes: int64(len(output))}
+	if len(output) <= outputInlineLimit {
+		stored.Preview = string(output)
+		return stored
+	}
+	stored.Preview = truncateUTF8(output, outputInlineLimit)
+
+	key := fmt.Sprintf("outputs/%s/%s", jobID, runID)
+	timeout := outputUploadTimeout
+	if abortRuns.Err() != nil {
+		timeout = shutdownUploadTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	_, err := s3Client.Client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      aws.String(outputBucket),
+		Key:         aws.String(key),
+		Body:        bytes.NewReader(output),
+		ContentType: aws.String("text/plain; charset=utf-8"),
+	})
+	observability.S3OperationsTotal.WithLabelValues("upload").Inc()
+	if err != nil {
+		log.Printf("Failed to upload output of run %s to S3, keeping %d-byte preview only: %v", runID, len(stored.Preview), err)
+		return stored
+	}
+	stored.Ref = "s3:" + key
+	return stored
+}
+
+// truncateUTF8 cuts b to at most n bytes without splitting a rune.
+func truncateUTF8(b []byte, n int) string {
+	if len(b) <= n {
+		return string(b)
+	}
+	cut := n
+	for cut > 0 && cut > n-utf8.UTFMax && !utf8.RuneStart(b[cut]) {
+		cut--
+	}
+	return string(b[:cut])
+}
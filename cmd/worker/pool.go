@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"distributed_job_scheduler/pkg/observability"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+// SQS returns at most 10 messages per receive.
+const maxReceiveBatch = 10
+
+// Number of jobs this worker executes at once.
+var workerConcurrency = intFromEnv("WORKER_CONCURRENCY", 10)
+
+// Visibility window kept on in-flight messages; heartbeats renew it every third.
+// SQS works in whole seconds, so anything shorter than 3s is rounded up.
+var visibilityTimeout = max(durationFromEnv("VISIBILITY_TIMEOUT", 30*time.Second), 3*time.Second)
+
+func intFromEnv(name string, def int) int {
+	if v := os.Getenv(name); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+		log.Printf("Invalid %s %q, using %d", name, v, def)
+	}
+	return def
+}
+
+// executorPool bounds concurrent executions. Receiving from SQS only happens
+// once a slot is free, so messages never sit in memory with a ticking
+// visibility timeout while waiting for a busy worker.
+type executorPool struct {
+	slots chan struct{}
+	wg    sync.WaitGroup
+}
+
+func newExecutorPool(size int) *executorPool {
+	return &executorPool{slots: make(chan struct{}, size)}
+}
+
+// acquire blocks for one slot, then grabs up to max-1 more without waiting.
+// It returns the number of slots held.
+func (p *executorPool) acquire(ctx context.Context, max int) int {
+	select {
+	case p.slots <- struct{}{}:
+	case <-ctx.Done():
+		return 0
+	}
+	n := 1
+	for n < max {
+		select {
+		case p.slots <- struct{}{}:
+			n++
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+func (p *executorPool) release(n int) {
+	for i := 0; i < n; i++ {
+		<-p.slots
+	}
+}
+
+// run executes one message on a held slot and frees it when done.
+func (p *executorPool) run(ctx context.Context, msg types.Message) {
+	p.wg.Add(1)
+	observability.WorkerActiveJobs.Inc()
+	go func() {
+		defer p.wg.Done()
+		defer p.release(1)
+		defer observability.WorkerActiveJobs.Dec()
+
+		stop := startVisibilityHeartbeat(ctx, msg)
+		defer stop()
+		processMessage(ctx, msg)
+	}()
+}
+
+// startVisibilityHeartbeat keeps msg invisible to other workers while it runs.
+// The returned func stops the heartbeat and waits for it to exit.
+func startVisibilityHeartbeat(ctx context.Context, msg types.Message) func() {
+	if msg.ReceiptHandle == nil {
+		return func() {}
+	}
+	hbCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(visibilityTimeout / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-hbCtx.Done():
+				return
+			case <-ticker.C:
+				err := sqsClient.ChangeMessageVisibility(hbCtx, *msg.ReceiptHandle, int32(visibilityTimeout.Seconds()))
+				if hbCtx.Err() != nil {
+					return // finished while the call was in flight
+				}
+				if err != nil {
+					log.Printf("Failed to extend visibility of message %s: %v", aws.ToString(msg.MessageId), err)
+					observability.VisibilityExtensionsTotal.WithLabelValues("error").Inc()
+					continue
+				}
+				observability.VisibilityExtensionsTotal.WithLabelValues("ok").Inc()
+			}
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"strconv"
+	"time"
+
+	"distributed_job_scheduler/pkg/observability"
+)
+
+// retryPolicy controls how long a failed run waits before it is re-enqueued.
+// The delay for attempt n (1-based) is base * 2^(n-1), capped at max, then
+// spread by +/- jitter so a burst of failures doesn't retry in lockstep.
+type retryPolicy struct {
+	base   time.Duration
+	max    time.Duration
+	jitter float64
+}
+
+var retries = loadRetryPolicy()
+
+func loadRetryPolicy() retryPolicy {
+	p := retryPolicy{
+		base:   5 * time.Second,
+		max:    5 * time.Minute,
+		jitter: 0.2,
+	}
+	if v := os.Getenv("RETRY_BASE_DELAY"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			p.base = d
+		} else {
+			log.Printf("Invalid RETRY_BASE_DELAY %q, using %v", v, p.base)
+		}
+	}
+	if v := os.Getenv("RETRY_MAX_DELAY"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			p.max = d
+		} else {
+			log.Printf("Invalid RETRY_MAX_DELAY %q, using %v", v, p.max)
+		}
+	}
+	if v := os.Getenv("RETRY_JITTER"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 && f <= 1 {
+			p.jitter = f
+		} else {
+			log.Printf("Invalid RETRY_JITTER %q, using %v", v, p.jitter)
+		}
+	}
+	return p
+}
+
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	delay := float64(p.base) * math.Pow(2, float64(attempt-1))
+	if delay > float64(p.max) {
+		delay = float64(p.max)
+	}
+	if p.jitter > 0 {
+		delay *= 1 + p.jitter*(2*rand.Float64()-1)
+	}
+	return time.Duration(delay)
+}
+
+// scheduleRetry bumps retry_count and puts the job back into job_queue after
+// the backoff delay. The picker dispatches it like any other due job.
+func scheduleRetry(event JobExecutionEvent) error {
+	attempt := event.RetryCount + 1
+	delay := retries.backoff(attempt)
+	nextFireAt := time.Now().Add(delay)
+	shardID := rand.Intn(1024)
+
+	log.Printf("Retrying job %s in %v (retry %d/%d, Shard %d)", event.JobID, delay, attempt, event.MaxRetries, shardID)
+
+	enqueue, err := advanceJob(event.JobID, nextFireAt, shardID, attempt, "RETRYING", false)
+	if err != nil || !enqueue {
+		return err
+	}
+
+	queueQuery := `INSERT INTO job_queue (shard_id, next_fire_at, job_id, status) VALUES (?, ?, ?, ?)`
+	if err := scyllaClient.Session.Query(queueQuery, shardID, nextFireAt, event.JobID, "PENDING").Exec(); err != nil {
+		return err
+	}
+
+	observability.JobRetriesTotal.Inc()
+	updateUserJobStatus(event.JobID, event.UserID, "RETRYING")
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"time"
+
+	"distributed_job_scheduler/pkg/executor"
+)
+
+// nobody/nogroup on Debian, which the worker image is built on.
+const defaultSandboxID = 65534
+
+// loadSandbox configures how cmd jobs are confined. Commands run as
+// SANDBOX_UID/SANDBOX_GID (nobody by default, -1 keeps the worker's user),
+// under rlimits, in a per-run scratch directory and, with
+// SANDBOX_CGROUP_DIR, in a per-run cgroup. A worker that can't switch to
+// SANDBOX_UID refuses to start rather than run commands as itself.
+func loadSandbox() executor.Sandbox {
+	sb := executor.Sandbox{
+		Limits: executor.Limits{
+			CPUTime:   durationFromEnv("SANDBOX_CPU_TIME", 1*time.Hour),
+			Memory:    uint64(intFromEnv("SANDBOX_MEMORY_MB", 1024)) << 20,
+			OpenFiles: uint64(intFromEnv("SANDBOX_OPEN_FILES", 1024)),
+			Processes: uint64(intFromEnv("SANDBOX_PROCESSES", 512)),
+		},
+		ScratchDir: os.Getenv("SANDBOX_SCRATCH_DIR"),
+		CgroupDir:  os.Getenv("SANDBOX_CGROUP_DIR"),
+	}
+
+	uid, gid := idFromEnv("SANDBOX_UID"), idFromEnv("SANDBOX_GID")
+	switch {
+	case uid == -1:
+		log.Println("SANDBOX_UID is -1: commands run as the worker user")
+	case os.Geteuid() != 0:
+		log.Fatalf("Worker is not root (uid %d) and can't run commands as SANDBOX_UID %d; set SANDBOX_UID=-1 to run them as the worker user", os.Geteuid(), uid)
+	default:
+		if gid == -1 {
+			gid = os.Getegid()
+		}
+		sb.Credential = &syscall.Credential{Uid: uint32(uid), Gid: uint32(gid)}
+	}
+
+	if sb.ScratchDir == "" {
+		sb.ScratchDir = filepath.Join(os.TempDir(), "job-runs")
+	}
+	// Traversable but not listable, so runs can't find each other's directories
+	err := os.MkdirAll(sb.ScratchDir, 0711)
+	if err == nil {
+		err = os.Chmod(sb.ScratchDir, 0711)
+	}
+	if err != nil {
+		log.Fatalf("Failed to create scratch directory %s: %v", sb.ScratchDir, err)
+	}
+
+	if sb.CgroupDir != "" {
+		if err := sb.EnableCgroupControllers(); err != nil {
+			log.Printf("cgroup limits disabled: %v", err)
+			sb.CgroupDir = ""
+		}
+	}
+
+	log.Printf("Command sandbox: credential %+v, limits %+v, scratch %s, cgroup %q",
+		sb.Credential, sb.Limits, sb.ScratchDir, sb.CgroupDir)
+	return sb
+}
+
+// idFromEnv reads a UID or GID, defaulting to nobody.
+func idFromEnv(name string) int {
+	if v := os.Getenv(name); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= -1 {
+			return n
+		}
+		log.Printf("Invalid %s %q, using %d", name, v, defaultSandboxID)
+	}
+	return defaultSandboxID
+}
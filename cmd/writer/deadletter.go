@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/confluentinc/confluent-kafka-go/v2/kafka"
+)
+
+// Events that can never be written to job_queue end up here.
+const deadLetterTopic = "job-submissions-dlq"
+
+// How often handled offsets are committed while consuming.
+const commitInterval = 1 * time.Second
+
+const (
+	retryInitialDelay = 200 * time.Millisecond
+	retryMaxDelay     = 10 * time.Second
+)
+
+// permanentError marks an event that retrying can't fix (bad JSON, invalid
+// fields). Anything else is treated as transient.
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func permanent(err error) error { return &permanentError{err: err} }
+
+// deadLetterEnvelope wraps the original message with where it came from and
+// why it was rejected, so it can be inspected and replayed by hand.
+type deadLetterEnvelope struct {
+	Topic     string `json:"topic"`
+	Partition int32  `json:"partition"`
+	Offset    int64  `json:"offset"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+	Error     string `json:"error"`
+	FailedAt  string `json:"failed_at"`
+}
+
+func deadLetter(msg *kafka.Message, cause error) error {
+	env := deadLetterEnvelope{
+		Partition: msg.TopicPartition.Partition,
+		Offset:    int64(msg.TopicPartition.Offset),
+		Key:       string(msg.Key),
+		Value:     string(msg.Value),
+		Error:     cause.Error(),
+		FailedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	if msg.TopicPartition.Topic != nil {
+		env.Topic = *msg.TopicPartition.Topic
+	}
+	b, err := json.Marshal(env)
+	if err != nil {
+		return err
+	}
+	return dlqProducer.Publish(string(msg.Key), b)
+}
+
+// retryWithBackoff calls fn until it succeeds, doubling the delay between
+// attempts up to retryMaxDelay. It only gives up when ctx is cancelled.
+func retryWithBackoff(ctx context.Context, fn func() error) error {
+	delay := retryInitialDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+		log.Printf("Attempt %d failed, retrying in %v: %v", attempt, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, retryMaxDelay)
+	}
+}
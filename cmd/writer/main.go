@@ -1,14 +1,20 @@
 package main
 
 import (
+    "context"
     "encoding/json"
+    "errors"
+    "fmt"
     "log"
     "os"
     "strings"
     "time"
 
     "distributed_job_scheduler/pkg/infra"
+    "distributed_job_scheduler/pkg/sharding"
+    "distributed_job_scheduler/pkg/shutdown"
     "github.com/confluentinc/confluent-kafka-go/v2/kafka"
+    "github.com/gocql/gocql"
 )
 
 type JobSubmissionEvent struct {
@@ -22,6 +28,7 @@ type JobSubmissionEvent struct {
 var (
     scyllaClient *infra.ScyllaClient
     kafkaConsumer *infra.KafkaConsumer
+    dlqProducer   *infra.KafkaProducer
 )
 
 func main() {
@@ -30,9 +37,13 @@ func main() {
     initInfra()
     defer closeInfra()
 
+    ctx, stop := shutdown.NotifyContext()
+    defer stop()
+
     log.Println("Writer Service started. Consuming messages...")
     
-    runLoop()
+    runLoop(ctx)
+    log.Println("Writer Service stopped")
 }
 
 func initInfra() {
@@ -56,40 +67,108 @@ func initInfra() {
     if err != nil {
         log.Fatalf("Failed to connect to Kafka: %v", err)
     }
+    dlqProducer, err = infra.NewKafkaProducer(kafkaBrokers, deadLetterTopic)
+    if err != nil {
+        log.Fatalf("Failed to create dead-letter producer: %v", err)
+    }
     log.Println("Connected to Kafka")
 }
 
 func closeInfra() {
     if scyllaClient != nil { scyllaClient.Close() }
     if kafkaConsumer != nil { kafkaConsumer.Close() }
+    if dlqProducer != nil { dlqProducer.Close() }
 }
 
-func runLoop() {
-    for {
+// runLoop consumes until ctx is cancelled. A message counts as handled once
+// its job_queue row is durably written or it has been dead-lettered; only
+// handled offsets are committed, every commitInterval and on shutdown, so a
+// restart resumes after the last handled message instead of replaying.
+func runLoop(ctx context.Context) {
+    handled := map[int32]kafka.TopicPartition{}
+    lastCommit := time.Now()
+
+    for ctx.Err() == nil {
+        if time.Since(lastCommit) >= commitInterval {
+            commitHandled(handled)
+            lastCommit = time.Now()
+        }
+
         msg, err := kafkaConsumer.ReadMessage(100 * time.Millisecond)
         if err != nil {
             // Ignore timeouts, log others
-            if err.(kafka.Error).Code() != kafka.ErrTimedOut {
+            if kerr, ok := err.(kafka.Error); !ok || kerr.Code() != kafka.ErrTimedOut {
                 log.Printf("Consumer error: %v (%v)", err, msg)
             }
             continue
         }
 
-        processMessage(msg)
+        if err := handleMessage(ctx, msg); err != nil {
+            // Only happens on shutdown; the message is re-read after restart
+            log.Printf("Stopped before message %v was handled: %v", msg.TopicPartition, err)
+            break
+        }
+
+        tp := msg.TopicPartition
+        tp.Offset++ // committed offset is the next one to read
+        handled[tp.Partition] = tp
+    }
+
+    log.Println("Shutdown signal received, committing offsets...")
+    commitHandled(handled)
+}
+
+// commitHandled commits and forgets the handled offsets. On failure they are
+// kept and retried with the next commit.
+func commitHandled(handled map[int32]kafka.TopicPartition) {
+    if len(handled) == 0 {
+        return
+    }
+    offsets := make([]kafka.TopicPartition, 0, len(handled))
+    for _, tp := range handled {
+        offsets = append(offsets, tp)
+    }
+    if _, err := kafkaConsumer.CommitOffsets(offsets); err != nil {
+        log.Printf("Failed to commit offsets: %v", err)
+        return
+    }
+    for p := range handled {
+        delete(handled, p)
     }
 }
 
-func processMessage(msg *kafka.Message) {
+// handleMessage writes msg to job_queue, retrying transient errors with
+// backoff until it succeeds or ctx is cancelled. Events that can never be
+// written are sent to the dead-letter topic instead.
+func handleMessage(ctx context.Context, msg *kafka.Message) error {
+    return retryWithBackoff(ctx, func() error {
+        err := processMessage(msg)
+        var perm *permanentError
+        if errors.As(err, &perm) {
+            log.Printf("Dead-lettering message %v: %v", msg.TopicPartition, perm.err)
+            return deadLetter(msg, perm.err)
+        }
+        return err
+    })
+}
+
+func processMessage(msg *kafka.Message) error {
     var event JobSubmissionEvent
     if err := json.Unmarshal(msg.Value, &event); err != nil {
-        log.Printf("Failed to unmarshal message: %v", err)
-        return
+        return permanent(fmt.Errorf("unmarshal message: %w", err))
     }
 
-    nextFireAt, err := time.Parse(time.RFC3339, event.NextFireAt)
+    if _, err := gocql.ParseUUID(event.JobID); err != nil {
+        return permanent(fmt.Errorf("invalid job_id %q: %w", event.JobID, err))
+    }
+
+    if event.ShardID < 0 || event.ShardID >= sharding.NumShards {
+        return permanent(fmt.Errorf("shard_id %d out of range", event.ShardID))
+    }
+
+    nextFireAt, err := time.Parse(time.RFC3339Nano, event.NextFireAt)
     if err != nil {
-        log.Printf("Failed to parse next_fire_at: %v", err)
-        return
+        return permanent(fmt.Errorf("parse next_fire_at: %w", err))
     }
 
     // Insert into job_queue
@@ -102,10 +181,9 @@ func processMessage(msg *kafka.Message) {
         "PENDING").Exec()
 
     if err != nil {
-        log.Printf("Scylla write failed for job %s: %v", event.JobID, err)
-        // Ensure retry logic or dead-letter queue in production
-        return
+        return fmt.Errorf("scylla write for job %s: %w", event.JobID, err)
     }
 
     log.Printf("Processed job %s (Shard: %d, FireAt: %s)", event.JobID, event.ShardID, event.NextFireAt)
+    return nil
 }
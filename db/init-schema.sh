@@ -26,17 +26,33 @@ if [ $RETRY_COUNT -eq $MAX_RETRIES ]; then
     exit 1
 fi
 
-# Check if schema already exists
-if cqlsh $SCYLLA_HOST -e "USE scheduler;" > /dev/null 2>&1; then
-    echo "ℹ️  Schema already exists, skipping initialization"
-    exit 0
-fi
-
-# Initialize schema
-echo "🔧 Initializing schema from /opt/schema.cql..."
-if cqlsh $SCYLLA_HOST -f /opt/schema.cql; then
-    echo "✅ Schema initialized successfully!"
-else
+# Create the keyspace and any missing tables (every statement is IF NOT EXISTS)
+echo "🔧 Applying schema from /opt/schema.cql..."
+if ! cqlsh $SCYLLA_HOST -f /opt/schema.cql; then
     echo "❌ Failed to initialize schema"
     exit 1
 fi
+
+# Add columns that tables created by an older schema.cql are missing
+if [ -f /opt/migrations.cql ]; then
+    echo "🔧 Applying column migrations from /opt/migrations.cql..."
+    APPLIED=0
+    while IFS= read -r STATEMENT; do
+        case "$STATEMENT" in
+            ""|--*) continue ;;
+        esac
+        if OUTPUT=$(cqlsh $SCYLLA_HOST -e "$STATEMENT" 2>&1); then
+            echo "   + $STATEMENT"
+            APPLIED=$((APPLIED + 1))
+        elif echo "$OUTPUT" | grep -qi "conflicts with an existing column\|already exists"; then
+            continue
+        else
+            echo "❌ Migration failed: $STATEMENT"
+            echo "$OUTPUT"
+            exit 1
+        fi
+    done < /opt/migrations.cql
+    echo "ℹ️  $APPLIED column(s) added"
+fi
+
+echo "✅ Schema initialized successfully!"
@@ -0,0 +1,49 @@
+package executor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func init() {
+	Register("cmd", Command{})
+	Register("sleep", Sleep{})
+	Register("echo", Echo{})
+	Register("http", HTTP{})
+}
+
+// Sleep waits for the duration in its payload (e.g. "30s"), or until the run
+// is stopped. Used for load and failure testing.
+type Sleep struct{}
+
+func (Sleep) ValidatePayload(payload string) error {
+	d, err := time.ParseDuration(payload)
+	if err != nil {
+		return fmt.Errorf("sleep payload must be a duration such as 30s: %v", err)
+	}
+	if d < 0 {
+		return errors.New("sleep duration must not be negative")
+	}
+	return nil
+}
+
+func (s Sleep) Execute(ctx context.Context, req Request) Result {
+	if err := s.ValidatePayload(req.Payload); err != nil {
+		return Result{Err: err}
+	}
+	d, _ := time.ParseDuration(req.Payload)
+	select {
+	case <-time.After(d):
+	case <-ctx.Done():
+	}
+	return Result{Output: []byte("slept " + req.Payload)}
+}
+
+// Echo succeeds immediately with its payload as output.
+type Echo struct{}
+
+func (Echo) Execute(ctx context.Context, req Request) Result {
+	return Result{Output: []byte(req.Payload)}
+}
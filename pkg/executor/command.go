@@ -0,0 +1,213 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// KillGracePeriod is how long a process group gets between SIGTERM and
+// SIGKILL. The worker sets it from KILL_GRACE_PERIOD.
+var KillGracePeriod = 5 * time.Second
+
+// CommandSpec is the structured form of a cmd payload: a JSON object with
+// an "argv" key. Any other payload is the older form, one string run by
+// `sh -c`, even if it starts with "{" (a brace group like `{ a; b; } > out`).
+//
+// Argv is executed directly, without a shell. With Shell set, Argv[0] is a
+// script for `sh -c` and the remaining elements become its $1, $2, ...
+type CommandSpec struct {
+	Argv  []string          `json:"argv"`
+	Env   map[string]string `json:"env"`   // added to the worker's environment
+	Cwd   string            `json:"cwd"`   // absolute; default is the worker's directory
+	Stdin string            `json:"stdin"` // default: empty
+	Shell bool              `json:"shell"`
+}
+
+// ParseCommandSpec accepts either payload form.
+func ParseCommandSpec(payload string) (CommandSpec, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil || fields["argv"] == nil {
+		if payload == "" {
+			return CommandSpec{}, errors.New("cmd payload must not be empty")
+		}
+		return CommandSpec{Argv: []string{payload}, Shell: true}, nil
+	}
+
+	var spec CommandSpec
+	dec := json.NewDecoder(strings.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return spec, fmt.Errorf("cmd payload must be a shell command or a JSON object with argv, env, cwd, stdin, shell: %v", err)
+	}
+	if len(spec.Argv) == 0 || spec.Argv[0] == "" {
+		return spec, errors.New("cmd argv must not be empty")
+	}
+	for k := range spec.Env {
+		if k == "" || strings.ContainsAny(k, "=\x00") {
+			return spec, fmt.Errorf("invalid environment variable name %q", k)
+		}
+	}
+	if spec.Cwd != "" && !filepath.IsAbs(spec.Cwd) {
+		return spec, fmt.Errorf("cwd must be an absolute path, got %q", spec.Cwd)
+	}
+	return spec, nil
+}
+
+// Command runs a CommandSpec.
+type Command struct{}
+
+func (Command) ValidatePayload(payload string) error {
+	_, err := ParseCommandSpec(payload)
+	return err
+}
+
+// AuthorizeProject enforces CommandProjects.
+func (Command) AuthorizeProject(projectID string) error {
+	if !CommandAllowed(projectID) {
+		return fmt.Errorf("project %q is not allowed to run commands", projectID)
+	}
+	return nil
+}
+
+// Execute runs the command in its own process group, confined by
+// CommandSandbox, passing its output to req.Logs as it is written. When ctx
+// ends (timeout or cancellation) the whole group gets SIGTERM, then SIGKILL
+// after KillGracePeriod, so background children of the script die too and
+// can't keep the output pipe open.
+func (c Command) Execute(ctx context.Context, req Request) Result {
+	if err := c.AuthorizeProject(req.ProjectID); err != nil {
+		return Result{Err: err}
+	}
+	spec, err := ParseCommandSpec(req.Payload)
+	if err != nil {
+		return Result{Err: err}
+	}
+
+	grace := KillGracePeriod
+	var cmd *exec.Cmd
+	if spec.Shell {
+		// "sh" fills $0 so the remaining argv lines up with $1...
+		cmd = exec.CommandContext(ctx, "sh", append([]string{"-c", spec.Argv[0], "sh"}, spec.Argv[1:]...)...)
+	} else {
+		cmd = exec.CommandContext(ctx, spec.Argv[0], spec.Argv[1:]...)
+	}
+	if len(spec.Env) > 0 {
+		cmd.Env = append(os.Environ(), sortedEnv(spec.Env)...)
+	}
+	cmd.Dir = spec.Cwd
+	cmd.Stdin = strings.NewReader(spec.Stdin)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	cleanup, err := CommandSandbox.confine(cmd, req.RunID, spec)
+	if err != nil {
+		return Result{Err: err}
+	}
+	defer cleanup()
+
+	var mu sync.Mutex
+	var lastSignal string
+	signalGroup := func(sig syscall.Signal) {
+		if cmd.Process == nil {
+			return
+		}
+		mu.Lock()
+		lastSignal = signalName(sig)
+		mu.Unlock()
+		// Negative pid targets the process group
+		syscall.Kill(-cmd.Process.Pid, sig)
+	}
+
+	var killTimer *time.Timer
+	cmd.Cancel = func() error {
+		signalGroup(syscall.SIGTERM)
+		mu.Lock()
+		killTimer = time.AfterFunc(grace, func() { signalGroup(syscall.SIGKILL) })
+		mu.Unlock()
+		return nil
+	}
+	// Backstop: stop waiting on pipes held by anything that escaped the group
+	cmd.WaitDelay = grace + time.Second
+
+	// Without a sink both streams share one pipe, which keeps their
+	// interleaving exact; with one, each needs its own to tell them apart.
+	var output outputBuffer
+	var streams []*lineWriter
+	if req.Logs == nil {
+		cmd.Stdout, cmd.Stderr = &output, &output
+	} else {
+		stdout := &lineWriter{stream: "stdout", sink: req.Logs, out: &output}
+		stderr := &lineWriter{stream: "stderr", sink: req.Logs, out: &output}
+		cmd.Stdout, cmd.Stderr = stdout, stderr
+		streams = []*lineWriter{stdout, stderr}
+	}
+
+	err = cmd.Run()
+	for _, w := range streams {
+		w.flush()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if killTimer != nil {
+		killTimer.Stop()
+		// The leader is gone; make sure nothing it started outlives the run
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+
+	result := Result{Output: output.Bytes(), Signal: lastSignal}
+	if cmd.ProcessState == nil {
+		// Never started (e.g. argv[0] not found)
+		result.Err = fmt.Errorf("starting command: %w", err)
+		return result
+	}
+	if ws, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); ok && ws.Signaled() {
+		result.TermSignal = signalName(ws.Signal())
+		result.Err = fmt.Errorf("terminated by %s", result.TermSignal)
+		return result
+	}
+	code := cmd.ProcessState.ExitCode()
+	result.ExitCode = &code
+	switch {
+	case code != 0:
+		result.Err = fmt.Errorf("exited with status %d", code)
+	case err != nil:
+		// Exited 0, but e.g. WaitDelay expired on a pipe held by an escaped child
+		result.Err = err
+	}
+	return result
+}
+
+// sortedEnv formats env as KEY=value entries in a stable order.
+func sortedEnv(env map[string]string) []string {
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]string, len(keys))
+	for i, k := range keys {
+		kvs[i] = k + "=" + env[k]
+	}
+	return kvs
+}
+
+func signalName(sig syscall.Signal) string {
+	switch sig {
+	case syscall.SIGTERM:
+		return "SIGTERM"
+	case syscall.SIGKILL:
+		return "SIGKILL"
+	default:
+		return sig.String()
+	}
+}
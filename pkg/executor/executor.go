@@ -0,0 +1,143 @@
+// Package executor runs job payloads. Each job has a job_type naming the
+// Executor that runs it; ingestion rejects types that aren't registered and
+// the worker looks the executor up instead of inspecting the payload.
+//
+// To add a job type, implement Executor in this package and Register it from
+// an init function, so ingestion and the worker agree on what exists.
+package executor
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Request is one run of a job.
+type Request struct {
+	JobID     string
+	RunID     string
+	ProjectID string
+	Payload   string  // with any legacy "<type>:" prefix removed
+	Logs      LogSink // receives output while the run executes; nil if unused
+}
+
+// Result is the outcome of a run. A non-nil Err marks the run FAILED; the
+// worker decides TIMED_OUT/CANCELLED itself from the context.
+type Result struct {
+	Output     []byte
+	Err        error
+	Signal     string        // last signal sent to a child process, "" if none
+	ExitCode   *int          // set when a child process exited normally
+	TermSignal string        // signal that terminated a child process
+	Response   *HTTPResponse // set by the http executor once a response arrived
+}
+
+// Executor runs payloads of one job type. Execute must return promptly once
+// ctx is done (timeout, cancellation or worker shutdown).
+type Executor interface {
+	Execute(ctx context.Context, req Request) Result
+}
+
+// PayloadValidator is optionally implemented by executors that can reject a
+// payload at submission instead of failing every run.
+type PayloadValidator interface {
+	ValidatePayload(payload string) error
+}
+
+// ProjectAuthorizer is optionally implemented by executors that only some
+// projects may use.
+type ProjectAuthorizer interface {
+	AuthorizeProject(projectID string) error
+}
+
+var (
+	mu        sync.RWMutex
+	executors = map[string]Executor{}
+)
+
+var validType = regexp.MustCompile(`^[a-z][a-z0-9_-]*$`)
+
+// Register makes e available as jobType. It panics on an invalid or
+// duplicate name, like http.Handle, since both are programming errors.
+func Register(jobType string, e Executor) {
+	if !validType.MatchString(jobType) {
+		panic(fmt.Sprintf("executor: invalid job type %q", jobType))
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := executors[jobType]; dup {
+		panic(fmt.Sprintf("executor: job type %q registered twice", jobType))
+	}
+	executors[jobType] = e
+}
+
+// Lookup returns the executor registered for jobType.
+func Lookup(jobType string) (Executor, bool) {
+	mu.RLock()
+	defer mu.RUnlock()
+	e, ok := executors[jobType]
+	return e, ok
+}
+
+// Types lists the registered job types, sorted.
+func Types() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	types := make([]string, 0, len(executors))
+	for t := range executors {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Payload prefixes that select a job type when none is given, the format used
+// before job_type existed. Newer types need an explicit job_type, so a
+// payload such as "http://..." is never mistaken for a prefix.
+var legacyPrefixes = map[string]bool{"cmd": true, "sleep": true, "echo": true}
+
+// Resolve determines the job type of a submission. An explicit jobType must
+// be registered and the payload is used as is. Without one, the type is taken
+// from a legacy "<type>:" payload prefix, which is stripped.
+func Resolve(jobType, payload string) (string, string, error) {
+	if jobType != "" {
+		if _, ok := Lookup(jobType); !ok {
+			return "", "", fmt.Errorf("unknown job_type %q (registered: %s)", jobType, strings.Join(Types(), ", "))
+		}
+		return jobType, payload, nil
+	}
+	if prefix, rest, ok := strings.Cut(payload, ":"); ok {
+		if _, registered := Lookup(prefix); registered && legacyPrefixes[prefix] {
+			return prefix, rest, nil
+		}
+	}
+	return "", "", fmt.Errorf("job_type is required (registered: %s)", strings.Join(Types(), ", "))
+}
+
+// Validate checks payload with the executor for jobType, if it validates.
+func Validate(jobType, payload string) error {
+	e, ok := Lookup(jobType)
+	if !ok {
+		return fmt.Errorf("unknown job_type %q", jobType)
+	}
+	if v, ok := e.(PayloadValidator); ok {
+		return v.ValidatePayload(payload)
+	}
+	return nil
+}
+
+// Authorize checks that projectID may use jobType, if its executor restricts
+// that.
+func Authorize(jobType, projectID string) error {
+	e, ok := Lookup(jobType)
+	if !ok {
+		return fmt.Errorf("unknown job_type %q", jobType)
+	}
+	if a, ok := e.(ProjectAuthorizer); ok {
+		return a.AuthorizeProject(projectID)
+	}
+	return nil
+}
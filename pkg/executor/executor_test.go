@@ -0,0 +1,155 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResolve(t *testing.T) {
+	cases := []struct {
+		jobType, payload   string
+		wantType, wantBody string
+		wantErr            bool
+	}{
+		{"cmd", "echo hi", "cmd", "echo hi", false},
+		{"echo", "cmd:not a prefix here", "echo", "cmd:not a prefix here", false},
+		{"", "cmd:ls -l", "cmd", "ls -l", false},
+		{"", "sleep:5s", "sleep", "5s", false},
+		{"", "echo:a:b", "echo", "a:b", false},
+		{"", "hello world", "", "", true},
+		{"", "http://example.com", "", "", true},
+		{"python", "print(1)", "", "", true},
+	}
+	for _, c := range cases {
+		typ, body, err := Resolve(c.jobType, c.payload)
+		if (err != nil) != c.wantErr || typ != c.wantType || body != c.wantBody {
+			t.Errorf("Resolve(%q, %q) = %q, %q, %v; want %q, %q, err=%v",
+				c.jobType, c.payload, typ, body, err, c.wantType, c.wantBody, c.wantErr)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate("sleep", "soon"); err == nil {
+		t.Error("sleep accepted a non-duration")
+	}
+	if err := Validate("sleep", "250ms"); err != nil {
+		t.Errorf("sleep rejected 250ms: %v", err)
+	}
+	if err := Validate("cmd", ""); err == nil {
+		t.Error("cmd accepted an empty command")
+	}
+	if err := Validate("echo", ""); err != nil {
+		t.Errorf("echo rejected an empty payload: %v", err)
+	}
+}
+
+func TestRegisterRejectsDuplicatesAndBadNames(t *testing.T) {
+	for _, name := range []string{"cmd", "Bad Name", ""} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Register(%q) did not panic", name)
+				}
+			}()
+			Register(name, Echo{})
+		}()
+	}
+}
+
+func TestCommandExecutor(t *testing.T) {
+	res := Command{}.Execute(context.Background(), Request{Payload: "echo out; echo err >&2"})
+	if res.Err != nil || !strings.Contains(string(res.Output), "out") || !strings.Contains(string(res.Output), "err") {
+		t.Errorf("unexpected result %q, %v", res.Output, res.Err)
+	}
+
+	res = Command{}.Execute(context.Background(), Request{Payload: "exit 3"})
+	if res.Err == nil || res.ExitCode == nil || *res.ExitCode != 3 || res.TermSignal != "" {
+		t.Errorf("expected exit code 3, got %v (err %v)", res.ExitCode, res.Err)
+	}
+}
+
+func TestCommandSpec(t *testing.T) {
+	dir := t.TempDir()
+	payload := `{"argv": ["sh", "-c", "echo \"$GREETING $1 from $(pwd)\"; cat", "ignored-0", "world"],
+		"env": {"GREETING": "hello"}, "cwd": "` + dir + `", "stdin": "piped input"}`
+	res := Command{}.Execute(context.Background(), Request{Payload: payload})
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v (%s)", res.Err, res.Output)
+	}
+	if want := "hello world from " + dir + "\npiped input"; string(res.Output) != want {
+		t.Errorf("output %q, want %q", res.Output, want)
+	}
+	if res.ExitCode == nil || *res.ExitCode != 0 {
+		t.Errorf("exit code %v, want 0", res.ExitCode)
+	}
+
+	// argv is not interpreted by a shell
+	res = Command{}.Execute(context.Background(), Request{Payload: `{"argv": ["echo", "$HOME; rm -rf /"]}`})
+	if string(res.Output) != "$HOME; rm -rf /\n" {
+		t.Errorf("argv was shell-expanded: %q", res.Output)
+	}
+
+	// shell: true runs argv[0] as a script with the rest as $1...
+	res = Command{}.Execute(context.Background(), Request{Payload: `{"shell": true, "argv": ["echo $1-$2 | tr a-z A-Z", "a b", "c"]}`})
+	if string(res.Output) != "A B-C\n" {
+		t.Errorf("shell output %q", res.Output)
+	}
+
+	res = Command{}.Execute(context.Background(), Request{Payload: `{"argv": ["/no/such/binary"]}`})
+	if res.Err == nil || res.ExitCode != nil {
+		t.Errorf("missing binary: err %v, exit code %v", res.Err, res.ExitCode)
+	}
+
+	for _, bad := range []string{`{"argv": []}`, `{"argv": ["ls"], "cwd": "relative"}`, `{"argv": ["ls"], "env": {"A=B": "c"}}`, `{"argv": ["ls"], "args": ["-l"]}`} {
+		if err := Validate("cmd", bad); err == nil {
+			t.Errorf("accepted %s", bad)
+		}
+	}
+}
+
+func TestCommandSpecShellFallback(t *testing.T) {
+	// Only a JSON object with argv is a spec; shell payloads may start with "{"
+	for _, payload := range []string{`{ echo a; echo b; } | tr a-z A-Z`, `{"args": ["ls"]}`, `{}`} {
+		spec, err := ParseCommandSpec(payload)
+		if err != nil || !spec.Shell || len(spec.Argv) != 1 || spec.Argv[0] != payload {
+			t.Errorf("%s: got %+v, %v; want it run by sh -c", payload, spec, err)
+		}
+	}
+
+	res := Command{}.Execute(context.Background(), Request{Payload: `{ echo a; echo b; } | tr a-z A-Z`})
+	if res.Err != nil || string(res.Output) != "A\nB\n" {
+		t.Errorf("brace group: output %q, err %v", res.Output, res.Err)
+	}
+}
+
+func TestCommandExecutorKillsProcessGroupOnCancel(t *testing.T) {
+	defer func(g time.Duration) { KillGracePeriod = g }(KillGracePeriod)
+	KillGracePeriod = 100 * time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	// The background child holds the output pipe open unless the group is killed
+	res := Command{}.Execute(ctx, Request{Payload: "trap '' TERM; sleep 30 & sleep 30"})
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("command outlived its context by %v", elapsed)
+	}
+	if res.Signal != "SIGKILL" || res.TermSignal != "SIGKILL" || res.ExitCode != nil {
+		t.Errorf("expected the group to be SIGKILLed after ignoring SIGTERM, got sent %q, terminated by %q", res.Signal, res.TermSignal)
+	}
+}
+
+func TestSleepStopsOnCancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if res := (Sleep{}).Execute(ctx, Request{Payload: "1m"}); res.Err != nil {
+		t.Fatal(res.Err)
+	}
+	if time.Since(start) > 5*time.Second {
+		t.Error("sleep ignored cancellation")
+	}
+}
@@ -0,0 +1,151 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// MaxHTTPResponseBytes caps how much of a response body is kept as run output.
+const MaxHTTPResponseBytes = 1 << 20
+
+// HTTPSpec is the payload of an http job.
+type HTTPSpec struct {
+	Method          string            `json:"method"` // default GET
+	URL             string            `json:"url"`
+	Headers         map[string]string `json:"headers"`
+	Body            string            `json:"body"`
+	Timeout         string            `json:"timeout"`          // e.g. "10s"; the run's timeout_seconds still applies
+	SuccessCodes    []int             `json:"success_codes"`    // default: any 2xx
+	FollowRedirects bool              `json:"follow_redirects"` // default false, like curl
+}
+
+// HTTPResponse is what an http run records besides the body.
+type HTTPResponse struct {
+	StatusCode int
+	Headers    map[string]string // multiple values joined with ", "
+}
+
+// HTTP calls an endpoint described by an HTTPSpec. A status outside
+// SuccessCodes fails the run, so it is retried like any other failure.
+type HTTP struct{}
+
+var httpTransport = http.DefaultTransport.(*http.Transport).Clone()
+
+func parseHTTPSpec(payload string) (HTTPSpec, time.Duration, error) {
+	var spec HTTPSpec
+	dec := json.NewDecoder(strings.NewReader(payload))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&spec); err != nil {
+		return spec, 0, fmt.Errorf("http payload must be a JSON object with method, url, headers, body, timeout, success_codes, follow_redirects: %v", err)
+	}
+
+	if spec.Method == "" {
+		spec.Method = http.MethodGet
+	}
+	spec.Method = strings.ToUpper(spec.Method)
+	if strings.ContainsAny(spec.Method, " \t\r\n") {
+		return spec, 0, fmt.Errorf("invalid http method %q", spec.Method)
+	}
+	u, err := url.Parse(spec.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return spec, 0, fmt.Errorf("http url must be an absolute http(s) URL, got %q", spec.URL)
+	}
+	for _, code := range spec.SuccessCodes {
+		if code < 100 || code > 599 {
+			return spec, 0, fmt.Errorf("invalid success code %d", code)
+		}
+	}
+
+	var timeout time.Duration
+	if spec.Timeout != "" {
+		if timeout, err = time.ParseDuration(spec.Timeout); err != nil || timeout <= 0 {
+			return spec, 0, fmt.Errorf("http timeout must be a positive duration such as 10s, got %q", spec.Timeout)
+		}
+	}
+	return spec, timeout, nil
+}
+
+func (HTTP) ValidatePayload(payload string) error {
+	_, _, err := parseHTTPSpec(payload)
+	return err
+}
+
+func (HTTP) Execute(ctx context.Context, req Request) Result {
+	spec, timeout, err := parseHTTPSpec(req.Payload)
+	if err != nil {
+		return Result{Err: err}
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, spec.Method, spec.URL, strings.NewReader(spec.Body))
+	if err != nil {
+		return Result{Err: fmt.Errorf("building request: %w", err)}
+	}
+	for k, v := range spec.Headers {
+		httpReq.Header.Set(k, v)
+	}
+	if host := httpReq.Header.Get("Host"); host != "" {
+		httpReq.Host = host
+	}
+
+	client := &http.Client{Transport: httpTransport}
+	if !spec.FollowRedirects {
+		client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) && timeout > 0 && ctx.Err() != nil {
+			return Result{Err: fmt.Errorf("request timed out after %v: %w", timeout, err)}
+		}
+		return Result{Err: fmt.Errorf("request failed: %w", err)}
+	}
+	defer resp.Body.Close()
+
+	var body bytes.Buffer
+	_, readErr := io.Copy(&body, io.LimitReader(resp.Body, MaxHTTPResponseBytes))
+
+	result := Result{
+		Output:   body.Bytes(),
+		Response: &HTTPResponse{StatusCode: resp.StatusCode, Headers: flattenHeaders(resp.Header)},
+	}
+	switch {
+	case !successStatus(resp.StatusCode, spec.SuccessCodes):
+		result.Err = fmt.Errorf("%s %s returned %s", spec.Method, spec.URL, resp.Status)
+	case readErr != nil:
+		result.Err = fmt.Errorf("reading response body: %w", readErr)
+	}
+	return result
+}
+
+func successStatus(code int, accepted []int) bool {
+	if len(accepted) == 0 {
+		return code >= 200 && code < 300
+	}
+	for _, c := range accepted {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func flattenHeaders(h http.Header) map[string]string {
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
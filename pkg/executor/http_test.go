@@ -0,0 +1,131 @@
+package executor
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func httpPayload(t *testing.T, spec HTTPSpec) string {
+	t.Helper()
+	b, err := json.Marshal(spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestHTTPExecutorSendsRequest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.Method != http.MethodPost || r.Header.Get("X-Token") != "secret" || string(body) != `{"ping":1}` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("X-Request-Id", "abc")
+		w.Header().Add("Set-Cookie", "a=1")
+		w.Header().Add("Set-Cookie", "b=2")
+		io.WriteString(w, "pong")
+	}))
+	defer srv.Close()
+
+	res := HTTP{}.Execute(context.Background(), Request{Payload: httpPayload(t, HTTPSpec{
+		Method:  "post",
+		URL:     srv.URL + "/hook",
+		Headers: map[string]string{"X-Token": "secret"},
+		Body:    `{"ping":1}`,
+	})})
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+	if string(res.Output) != "pong" || res.Response == nil || res.Response.StatusCode != 200 {
+		t.Fatalf("unexpected result: output %q, response %+v", res.Output, res.Response)
+	}
+	if res.Response.Headers["X-Request-Id"] != "abc" || res.Response.Headers["Set-Cookie"] != "a=1, b=2" {
+		t.Errorf("headers not recorded: %v", res.Response.Headers)
+	}
+}
+
+func TestHTTPExecutorStatusDecidesSuccess(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		io.WriteString(w, "queued")
+	}))
+	defer srv.Close()
+
+	if res := (HTTP{}).Execute(context.Background(), Request{Payload: httpPayload(t, HTTPSpec{URL: srv.URL})}); res.Err != nil {
+		t.Errorf("202 should succeed by default: %v", res.Err)
+	}
+	res := HTTP{}.Execute(context.Background(), Request{Payload: httpPayload(t, HTTPSpec{URL: srv.URL, SuccessCodes: []int{200}})})
+	if res.Err == nil || !strings.Contains(res.Err.Error(), "202") {
+		t.Errorf("202 should fail when only 200 is accepted, got %v", res.Err)
+	}
+	if res.Response == nil || res.Response.StatusCode != 202 || string(res.Output) != "queued" {
+		t.Errorf("failed response should still be recorded: %+v %q", res.Response, res.Output)
+	}
+}
+
+func TestHTTPExecutorRedirects(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/old", func(w http.ResponseWriter, r *http.Request) { http.Redirect(w, r, "/new", http.StatusFound) })
+	mux.HandleFunc("/new", func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "moved") })
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	res := HTTP{}.Execute(context.Background(), Request{Payload: httpPayload(t, HTTPSpec{URL: srv.URL + "/old"})})
+	if res.Err == nil || res.Response.StatusCode != http.StatusFound || res.Response.Headers["Location"] != "/new" {
+		t.Errorf("redirect should not be followed by default: %+v, %v", res.Response, res.Err)
+	}
+	res = HTTP{}.Execute(context.Background(), Request{Payload: httpPayload(t, HTTPSpec{URL: srv.URL + "/old", FollowRedirects: true})})
+	if res.Err != nil || string(res.Output) != "moved" {
+		t.Errorf("redirect not followed: %q, %v", res.Output, res.Err)
+	}
+}
+
+func TestHTTPExecutorTimeoutAndBodyLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-time.After(5 * time.Second):
+			case <-r.Context().Done():
+			}
+			return
+		}
+		io.WriteString(w, strings.Repeat("x", MaxHTTPResponseBytes+100))
+	}))
+	defer srv.Close()
+
+	start := time.Now()
+	res := HTTP{}.Execute(context.Background(), Request{Payload: httpPayload(t, HTTPSpec{URL: srv.URL + "/slow", Timeout: "100ms"})})
+	if res.Err == nil || time.Since(start) > 3*time.Second {
+		t.Errorf("expected a timeout after 100ms, got %v after %v", res.Err, time.Since(start))
+	}
+
+	res = HTTP{}.Execute(context.Background(), Request{Payload: httpPayload(t, HTTPSpec{URL: srv.URL + "/big"})})
+	if res.Err != nil || len(res.Output) != MaxHTTPResponseBytes {
+		t.Errorf("expected body truncated to %d bytes, got %d (%v)", MaxHTTPResponseBytes, len(res.Output), res.Err)
+	}
+}
+
+func TestHTTPPayloadValidation(t *testing.T) {
+	for _, payload := range []string{
+		`not json`,
+		`{"url": "ftp://example.com"}`,
+		`{"url": "/relative"}`,
+		`{"url": "http://example.com", "timeout": "soon"}`,
+		`{"url": "http://example.com", "success_codes": [700]}`,
+		`{"url": "http://example.com", "retries": 3}`,
+	} {
+		if err := Validate("http", payload); err == nil {
+			t.Errorf("accepted %s", payload)
+		}
+	}
+	if err := Validate("http", `{"method": "PUT", "url": "https://svc.internal/hook", "timeout": "5s", "success_codes": [200, 204]}`); err != nil {
+		t.Errorf("rejected a valid spec: %v", err)
+	}
+}
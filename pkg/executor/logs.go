@@ -0,0 +1,85 @@
+package executor
+
+import (
+	"bytes"
+	"sync"
+	"time"
+)
+
+// LogSink receives a run's output while it executes, one line at a time
+// without the trailing newline. stream is "stdout" or "stderr". WriteLine is
+// called from the goroutines copying the process's pipes and must not block
+// for long.
+type LogSink interface {
+	WriteLine(stream string, ts time.Time, line string)
+}
+
+// Lines longer than this are passed on in pieces, so output without newlines
+// (progress bars, minified JSON) still shows up while the run executes.
+const maxLogLine = 16 << 10
+
+// outputBuffer collects the combined output of a run from both pipes.
+type outputBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *outputBuffer) Bytes() []byte {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Bytes()
+}
+
+// lineWriter is the stdout or stderr of a process: everything goes to the
+// combined output, and each complete line to the sink as well.
+type lineWriter struct {
+	stream  string
+	sink    LogSink
+	out     *outputBuffer
+	partial []byte
+	split   bool // part of the current line was already passed on
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	w.out.Write(p)
+	now := time.Now()
+	for len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		chunk := p
+		if i >= 0 {
+			chunk = p[:i]
+		}
+		w.partial = append(w.partial, chunk...)
+		for len(w.partial) >= maxLogLine {
+			w.sink.WriteLine(w.stream, now, string(w.partial[:maxLogLine]))
+			w.partial = append(w.partial[:0], w.partial[maxLogLine:]...)
+			w.split = true
+		}
+		if i < 0 {
+			break
+		}
+		// A line split exactly at its end has nothing left to pass on
+		if len(w.partial) > 0 || !w.split {
+			w.sink.WriteLine(w.stream, now, string(w.partial))
+		}
+		w.partial, w.split = w.partial[:0], false
+		p = p[i+1:]
+	}
+	return n, nil
+}
+
+// flush passes on a last line that had no newline. Call once the process's
+// output has been fully copied.
+func (w *lineWriter) flush() {
+	if len(w.partial) > 0 {
+		w.sink.WriteLine(w.stream, time.Now(), string(w.partial))
+		w.partial, w.split = nil, false
+	}
+}
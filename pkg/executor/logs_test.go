@@ -0,0 +1,65 @@
+package executor
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingSink struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (s *recordingSink) WriteLine(stream string, ts time.Time, line string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lines = append(s.lines, stream+": "+line)
+}
+
+func (s *recordingSink) only(stream string) []string {
+	var lines []string
+	for _, l := range s.lines {
+		if strings.HasPrefix(l, stream+": ") {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
+
+func TestCommandStreamsLines(t *testing.T) {
+	sink := &recordingSink{}
+	res := Command{}.Execute(context.Background(), Request{Logs: sink,
+		Payload: `echo one; echo oops >&2; printf 'two\nthree'`})
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+
+	if got, want := strings.Join(sink.only("stdout"), "|"), "stdout: one|stdout: two|stdout: three"; got != want {
+		t.Errorf("stdout lines %q, want %q", got, want)
+	}
+	if got, want := strings.Join(sink.only("stderr"), "|"), "stderr: oops"; got != want {
+		t.Errorf("stderr lines %q, want %q", got, want)
+	}
+	for _, s := range []string{"one\n", "oops\n", "two\nthree"} {
+		if !strings.Contains(string(res.Output), s) {
+			t.Errorf("combined output %q is missing %q", res.Output, s)
+		}
+	}
+}
+
+func TestLineWriterSplitsLongLines(t *testing.T) {
+	sink := &recordingSink{}
+	w := &lineWriter{stream: "stdout", sink: sink, out: &outputBuffer{}}
+	long := strings.Repeat("x", maxLogLine+10)
+	w.Write([]byte(long[:100]))
+	w.Write([]byte(long[100:] + "\nend"))
+	w.flush()
+
+	if len(sink.lines) != 3 || sink.lines[0] != "stdout: "+long[:maxLogLine] ||
+		sink.lines[1] != "stdout: xxxxxxxxxx" || sink.lines[2] != "stdout: end" {
+		t.Errorf("unexpected lines (%d)", len(sink.lines))
+	}
+}
@@ -0,0 +1,239 @@
+package executor
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// CommandSandbox confines cmd runs. The worker sets it from SANDBOX_*; the
+// zero value runs commands as the worker user, in its environment, unlimited.
+var CommandSandbox Sandbox
+
+// CommandProjects lists the projects allowed to run cmd jobs. Empty allows
+// none; AllProjects in the list allows every project. Ingestion and the
+// worker both set it from CMD_ALLOWED_PROJECTS, so submissions are rejected
+// up front and jobs stored before a project was removed fail when they run.
+var CommandProjects []string
+
+// AllProjects in CommandProjects lets every project run cmd jobs.
+const AllProjects = "*"
+
+// Sandbox describes how a command is confined.
+type Sandbox struct {
+	// Credential is the user and group the command runs as; nil keeps the
+	// worker's. Sandboxed commands also get a minimal environment instead of
+	// the worker's, which holds its own credentials.
+	Credential *syscall.Credential
+	Limits     Limits
+	// ScratchDir holds a private working directory per run, removed when the
+	// run ends. "" runs in the worker's directory.
+	ScratchDir string
+	// CgroupDir is a cgroup v2 directory delegated to the worker (and not
+	// containing it). Each run gets a child cgroup with memory.max and
+	// pids.max from Limits. "" disables cgroups.
+	CgroupDir string
+}
+
+// Limits are per-run resource limits; zero means unlimited. The rlimits apply
+// to each process of the run; the cgroup, if any, caps the run as a whole.
+type Limits struct {
+	CPUTime   time.Duration // RLIMIT_CPU
+	Memory    uint64        // bytes: RLIMIT_AS and memory.max
+	OpenFiles uint64        // RLIMIT_NOFILE
+	Processes uint64        // RLIMIT_NPROC (counted per user) and pids.max
+}
+
+// CommandAllowed reports whether projectID may run cmd jobs.
+func CommandAllowed(projectID string) bool {
+	for _, p := range CommandProjects {
+		if p == AllProjects || p == projectID {
+			return true
+		}
+	}
+	return false
+}
+
+// prlimitArgs returns the argv prefix that applies l to the command after it,
+// or nil when there is nothing to limit. prlimit sets the limits on itself
+// and execs, so they are in place before the command's first instruction.
+func (l Limits) prlimitArgs() []string {
+	var args []string
+	if l.CPUTime > 0 {
+		secs := int64((l.CPUTime + time.Second - 1) / time.Second)
+		args = append(args, "--cpu="+strconv.FormatInt(secs, 10))
+	}
+	if l.Memory > 0 {
+		args = append(args, "--as="+strconv.FormatUint(l.Memory, 10))
+	}
+	if l.OpenFiles > 0 {
+		args = append(args, "--nofile="+strconv.FormatUint(l.OpenFiles, 10))
+	}
+	if l.Processes > 0 {
+		args = append(args, "--nproc="+strconv.FormatUint(l.Processes, 10))
+	}
+	if args == nil {
+		return nil
+	}
+	return append(append([]string{"prlimit"}, args...), "--")
+}
+
+// environ is the base environment of a sandboxed command.
+func (s Sandbox) environ(scratch string) []string {
+	env := []string{"PATH=" + os.Getenv("PATH")}
+	if scratch != "" {
+		env = append(env, "HOME="+scratch, "TMPDIR="+scratch)
+	}
+	return env
+}
+
+// makeScratch creates the run's scratch directory, owned by the sandbox user.
+func (s Sandbox) makeScratch(runID string) (string, error) {
+	if s.ScratchDir == "" {
+		return "", nil
+	}
+	dir, err := os.MkdirTemp(s.ScratchDir, "run-"+runID+"-")
+	if err != nil {
+		return "", fmt.Errorf("creating scratch directory: %w", err)
+	}
+	if s.Credential != nil {
+		if err := os.Chown(dir, int(s.Credential.Uid), int(s.Credential.Gid)); err != nil {
+			os.RemoveAll(dir)
+			return "", fmt.Errorf("creating scratch directory: %w", err)
+		}
+	}
+	return dir, nil
+}
+
+// EnableCgroupControllers turns on the memory and pids controllers for the
+// children of CgroupDir. An error means the host doesn't allow it; the
+// worker then runs without cgroups.
+func (s Sandbox) EnableCgroupControllers() error {
+	if _, err := os.Stat(filepath.Join(s.CgroupDir, "cgroup.controllers")); err != nil {
+		return fmt.Errorf("%s is not a cgroup v2 directory: %w", s.CgroupDir, err)
+	}
+	return os.WriteFile(filepath.Join(s.CgroupDir, "cgroup.subtree_control"), []byte("+memory +pids"), 0)
+}
+
+// runCgroup is the cgroup of one run.
+type runCgroup struct {
+	dir string
+	fd  *os.File
+}
+
+// newCgroup creates the run's cgroup. The command is started directly inside
+// it through SysProcAttr.CgroupFD.
+func (s Sandbox) newCgroup(runID string) (*runCgroup, error) {
+	dir := filepath.Join(s.CgroupDir, "run-"+runID)
+	if err := os.Mkdir(dir, 0755); err != nil {
+		return nil, err
+	}
+	cg := &runCgroup{dir: dir}
+	limits := map[string]uint64{"memory.max": s.Limits.Memory, "pids.max": s.Limits.Processes}
+	for file, v := range limits {
+		if v == 0 {
+			continue
+		}
+		if err := os.WriteFile(filepath.Join(dir, file), []byte(strconv.FormatUint(v, 10)), 0); err != nil {
+			cg.remove()
+			return nil, err
+		}
+	}
+	fd, err := os.Open(dir)
+	if err != nil {
+		cg.remove()
+		return nil, err
+	}
+	cg.fd = fd
+	return cg, nil
+}
+
+// remove kills anything left in the cgroup and deletes it.
+func (cg *runCgroup) remove() error {
+	if cg.fd != nil {
+		cg.fd.Close()
+	}
+	// cgroup.kill needs Linux 5.14; the process group was killed anyway
+	os.WriteFile(filepath.Join(cg.dir, "cgroup.kill"), []byte("1"), 0)
+	var err error
+	for i := 0; i < 20; i++ {
+		// Fails with EBUSY until the killed processes have been reaped
+		if err = os.Remove(cg.dir); err == nil || errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return err
+}
+
+// confine applies s to cmd, which must not have been started yet. The
+// returned cleanup removes the run's scratch directory and cgroup.
+func (s Sandbox) confine(cmd *exec.Cmd, runID string, spec CommandSpec) (func(), error) {
+	if prefix := s.Limits.prlimitArgs(); prefix != nil {
+		path, err := exec.LookPath(prefix[0])
+		if err != nil {
+			return nil, fmt.Errorf("resource limits need prlimit (util-linux): %w", err)
+		}
+		cmd.Args = append(prefix, cmd.Args...)
+		cmd.Path = path
+		cmd.Err = nil
+	}
+
+	scratch, err := s.makeScratch(runID)
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() {
+		if scratch != "" {
+			os.RemoveAll(scratch)
+		}
+	}
+	if cmd.Dir == "" {
+		cmd.Dir = scratch
+	}
+
+	if s.Credential != nil {
+		cmd.SysProcAttr.Credential = s.Credential
+		cmd.Env = append(s.environ(scratch), sortedEnv(spec.Env)...)
+	} else if scratch != "" {
+		cmd.Env = append(append(os.Environ(), "TMPDIR="+scratch), sortedEnv(spec.Env)...)
+	}
+
+	if s.CgroupDir != "" {
+		cg, err := s.newCgroup(runID)
+		if err != nil {
+			// Limits still hold per process through the rlimits
+			log.Printf("Running %s without a cgroup: %v", runID, err)
+			return cleanup, nil
+		}
+		cmd.SysProcAttr.UseCgroupFD = true
+		cmd.SysProcAttr.CgroupFD = int(cg.fd.Fd())
+		removeScratch := cleanup
+		cleanup = func() {
+			if err := cg.remove(); err != nil {
+				log.Printf("Failed to remove cgroup %s: %v", cg.dir, err)
+			}
+			removeScratch()
+		}
+	}
+	return cleanup, nil
+}
+
+// ParseProjects splits a comma-separated project list such as
+// CMD_ALLOWED_PROJECTS.
+func ParseProjects(list string) []string {
+	var projects []string
+	for _, p := range strings.Split(list, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			projects = append(projects, p)
+		}
+	}
+	return projects
+}
@@ -0,0 +1,109 @@
+package executor
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// cmd jobs are refused to every project unless allowed; the other tests
+// run commands without a project.
+func TestMain(m *testing.M) {
+	CommandProjects = []string{AllProjects}
+	os.Exit(m.Run())
+}
+
+func useSandbox(t *testing.T, sb Sandbox) {
+	prev := CommandSandbox
+	CommandSandbox = sb
+	t.Cleanup(func() { CommandSandbox = prev })
+}
+
+func TestCommandProjects(t *testing.T) {
+	prev := CommandProjects
+	t.Cleanup(func() { CommandProjects = prev })
+
+	CommandProjects = ParseProjects("")
+	if err := Authorize("cmd", "ops"); err == nil {
+		t.Error("commands allowed with no projects configured")
+	}
+	CommandProjects = ParseProjects("*")
+	if err := Authorize("cmd", "ops"); err != nil {
+		t.Errorf("* did not allow ops: %v", err)
+	}
+
+	CommandProjects = ParseProjects(" ops, data ,,")
+	if len(CommandProjects) != 2 {
+		t.Fatalf("ParseProjects = %q", CommandProjects)
+	}
+	if err := Authorize("cmd", "ops"); err != nil {
+		t.Errorf("ops rejected: %v", err)
+	}
+	if err := Authorize("cmd", "marketing"); err == nil {
+		t.Error("marketing allowed to run commands")
+	}
+	if err := Authorize("echo", "marketing"); err != nil {
+		t.Errorf("echo restricted by the command allowlist: %v", err)
+	}
+	res := Command{}.Execute(context.Background(), Request{ProjectID: "marketing", Payload: "echo hi"})
+	if res.Err == nil || res.ExitCode != nil {
+		t.Errorf("expected the run to be refused before starting, got %q, %v", res.Output, res.Err)
+	}
+}
+
+func TestSandboxScratchAndLimits(t *testing.T) {
+	if _, err := exec.LookPath("prlimit"); err != nil {
+		t.Skip("prlimit not installed")
+	}
+	root := t.TempDir()
+	useSandbox(t, Sandbox{Limits: Limits{OpenFiles: 64, Processes: 4096}, ScratchDir: root})
+
+	res := Command{}.Execute(context.Background(), Request{RunID: "r1", Payload: `ulimit -n; pwd; echo "$TMPDIR"`})
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v (%s)", res.Err, res.Output)
+	}
+	lines := strings.Split(strings.TrimSpace(string(res.Output)), "\n")
+	if len(lines) != 3 || lines[0] != "64" {
+		t.Fatalf("unexpected output %q", res.Output)
+	}
+	if filepath.Dir(lines[1]) != root || lines[2] != lines[1] {
+		t.Errorf("expected cwd and TMPDIR in a scratch directory under %s, got %q", root, lines[1:])
+	}
+	if entries, _ := os.ReadDir(root); len(entries) != 0 {
+		t.Errorf("scratch directory not removed: %v", entries)
+	}
+
+	// An explicit cwd still wins over the scratch directory
+	res = Command{}.Execute(context.Background(), Request{RunID: "r2", Payload: `{"argv": ["pwd"], "cwd": "/"}`})
+	if strings.TrimSpace(string(res.Output)) != "/" {
+		t.Errorf("cwd ignored: %q", res.Output)
+	}
+}
+
+func TestSandboxCredential(t *testing.T) {
+	if os.Geteuid() != 0 {
+		t.Skip("needs root to switch users")
+	}
+	t.Setenv("WORKER_SECRET", "s3cr3t")
+	// The sandbox user has to reach its scratch directory, as in loadSandbox
+	root := t.TempDir()
+	for _, dir := range []string{filepath.Dir(root), root} {
+		if err := os.Chmod(dir, 0711); err != nil {
+			t.Fatal(err)
+		}
+	}
+	useSandbox(t, Sandbox{Credential: &syscall.Credential{Uid: 65534, Gid: 65534}, ScratchDir: root})
+
+	payload := `{"argv": ["sh", "-c", "id -u; id -g; echo \"secret=$WORKER_SECRET extra=$EXTRA\"; touch file"], "env": {"EXTRA": "x"}}`
+	res := Command{}.Execute(context.Background(), Request{RunID: "r1", Payload: payload})
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v (%s)", res.Err, res.Output)
+	}
+	if want := "65534\n65534\nsecret= extra=x\n"; string(res.Output) != want {
+		t.Errorf("output %q, want %q", res.Output, want)
+	}
+}
@@ -1,6 +1,7 @@
 package infra
 
 import (
+    "fmt"
     "time"
 
     "github.com/confluentinc/confluent-kafka-go/v2/kafka"
@@ -48,6 +49,33 @@ func (k *KafkaProducer) Publish(key string, payload []byte) error {
     return nil
 }
 
+// PublishWithTimeout is Publish, but gives up waiting for the delivery report
+// after timeout. The message may still be delivered later.
+func (k *KafkaProducer) PublishWithTimeout(key string, payload []byte, timeout time.Duration) error {
+    // Buffered so the delivery report never blocks the producer once we stop listening
+    deliveryChan := make(chan kafka.Event, 1)
+
+    err := k.Producer.Produce(&kafka.Message{
+        TopicPartition: kafka.TopicPartition{Topic: &k.Topic, Partition: kafka.PartitionAny},
+        Key:            []byte(key),
+        Value:          payload,
+    }, deliveryChan)
+
+    if err != nil {
+        return err
+    }
+
+    select {
+    case e := <-deliveryChan:
+        if m, ok := e.(*kafka.Message); ok && m.TopicPartition.Error != nil {
+            return m.TopicPartition.Error
+        }
+        return nil
+    case <-time.After(timeout):
+        return fmt.Errorf("kafka delivery not confirmed within %v", timeout)
+    }
+}
+
 
 func (k *KafkaProducer) Close() {
     k.Producer.Flush(15 * 1000)
@@ -86,6 +114,11 @@ func (k *KafkaConsumer) CommitMessage(msg *kafka.Message) ([]kafka.TopicPartitio
     return k.Consumer.CommitMessage(msg)
 }
 
+// CommitOffsets commits explicit offsets, e.g. the next offset to read per partition.
+func (k *KafkaConsumer) CommitOffsets(offsets []kafka.TopicPartition) ([]kafka.TopicPartition, error) {
+    return k.Consumer.CommitOffsets(offsets)
+}
+
 func (k *KafkaConsumer) Close() error {
     return k.Consumer.Close()
 }
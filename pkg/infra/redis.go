@@ -7,11 +7,41 @@ import (
     "github.com/redis/go-redis/v9"
 )
 
+// JobCancellationChannel carries job IDs cancelled via the API so workers
+// can stop runs that are already executing.
+const JobCancellationChannel = "job-cancellations"
+
+// RunLogStream is the Redis stream a worker writes a run's live log to while
+// it executes. Entries have a "type" of start, stdout, stderr, interrupted or
+// end, a "ts", and "line" (stdout/stderr) or "status" (end).
+func RunLogStream(jobID, runID string) string {
+    return "run-logs:" + jobID + ":" + runID
+}
+
+// LiveRunsKey is the Redis hash of a job's executing runs, run_id -> LiveRun
+// as JSON, so the API can list runs before they are recorded in job_runs.
+func LiveRunsKey(jobID string) string {
+    return "live-runs:" + jobID
+}
+
+// LiveRun describes a run that is executing on a worker.
+type LiveRun struct {
+    WorkerID  string    `json:"worker_id"`
+    Attempt   int       `json:"attempt"`
+    StartedAt time.Time `json:"started_at"`
+}
+
 type RedisClient struct {
     Client *redis.Client
 }
 
 func NewRedisClient(addr string, password string, db int) (*RedisClient, error) {
+    return NewRedisClientWithPool(addr, password, db, 10)
+}
+
+// NewRedisClientWithPool is NewRedisClient with its own connection pool
+// size, for callers that hold connections in blocking reads.
+func NewRedisClientWithPool(addr string, password string, db int, poolSize int) (*RedisClient, error) {
     rdb := redis.NewClient(&redis.Options{
         Addr:         addr,
         Password:     password,
@@ -19,7 +49,7 @@ func NewRedisClient(addr string, password string, db int) (*RedisClient, error)
         DialTimeout:  5 * time.Second,
         ReadTimeout:  3 * time.Second,
         WriteTimeout: 3 * time.Second,
-        PoolSize:     10,
+        PoolSize:     poolSize,
     })
 
     ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
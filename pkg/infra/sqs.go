@@ -4,10 +4,12 @@ import (
     "context"
     "fmt"
     "log"
+    "strconv"
 
     "github.com/aws/aws-sdk-go-v2/aws"
     "github.com/aws/aws-sdk-go-v2/config"
     "github.com/aws/aws-sdk-go-v2/service/sqs"
+    "github.com/aws/aws-sdk-go-v2/service/sqs/types"
 )
 
 type SQSClient struct {
@@ -55,6 +57,47 @@ func (s *SQSClient) SendMessage(ctx context.Context, body string) error {
     return err
 }
 
+// MaxBatchSize is the most entries SQS accepts in one SendMessageBatch call.
+const MaxBatchSize = 10
+
+// SendMessageBatch sends bodies in as few requests as possible. The returned
+// slice has one entry per body: nil if it was accepted, otherwise why not.
+// Callers only need to retry the entries that failed.
+func (s *SQSClient) SendMessageBatch(ctx context.Context, bodies []string) []error {
+    errs := make([]error, len(bodies))
+
+    for start := 0; start < len(bodies); start += MaxBatchSize {
+        end := min(start+MaxBatchSize, len(bodies))
+
+        entries := make([]types.SendMessageBatchRequestEntry, 0, end-start)
+        for i := start; i < end; i++ {
+            entries = append(entries, types.SendMessageBatchRequestEntry{
+                Id:          aws.String(strconv.Itoa(i)),
+                MessageBody: aws.String(bodies[i]),
+            })
+        }
+
+        out, err := s.Client.SendMessageBatch(ctx, &sqs.SendMessageBatchInput{
+            QueueUrl: &s.QueueURL,
+            Entries:  entries,
+        })
+        if err != nil {
+            for i := start; i < end; i++ {
+                errs[i] = err
+            }
+            continue
+        }
+        for _, f := range out.Failed {
+            i, convErr := strconv.Atoi(aws.ToString(f.Id))
+            if convErr != nil || i < start || i >= end {
+                continue
+            }
+            errs[i] = fmt.Errorf("%s: %s", aws.ToString(f.Code), aws.ToString(f.Message))
+        }
+    }
+    return errs
+}
+
 func (s *SQSClient) ReceiveMessages(ctx context.Context, maxMessages int32, waitTime int32) (*sqs.ReceiveMessageOutput, error) {
     return s.Client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
         QueueUrl:            &s.QueueURL,
@@ -70,3 +113,14 @@ func (s *SQSClient) DeleteMessage(ctx context.Context, receiptHandle string) err
     })
     return err
 }
+
+// ChangeMessageVisibility resets the visibility timeout of a received message,
+// counted from now. A timeout of 0 makes it immediately receivable again.
+func (s *SQSClient) ChangeMessageVisibility(ctx context.Context, receiptHandle string, timeoutSeconds int32) error {
+    _, err := s.Client.ChangeMessageVisibility(ctx, &sqs.ChangeMessageVisibilityInput{
+        QueueUrl:          &s.QueueURL,
+        ReceiptHandle:     &receiptHandle,
+        VisibilityTimeout: timeoutSeconds,
+    })
+    return err
+}
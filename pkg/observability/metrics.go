@@ -73,6 +73,33 @@ var (
 		Name: "sqs_enqueue_errors_total",
 		Help: "Total number of SQS enqueue errors",
 	})
+
+	PickerCycleDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "picker_cycle_duration_seconds",
+		Help:    "Time to scan all owned shards once; above 1s the picker falls behind its tick",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2, 5, 10, 30},
+	})
+
+	DispatchLag = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "dispatch_lag_seconds",
+		Help:    "Delay between a job's next_fire_at and its message being accepted by SQS",
+		Buckets: []float64{0.1, 0.5, 1, 2, 5, 10, 30, 60, 300},
+	})
+
+	PickerOldestDueSeconds = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "picker_oldest_due_seconds",
+		Help: "Age of the most overdue job_queue row seen in the last scan cycle",
+	})
+
+	ClaimConflictsTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "picker_claim_conflicts_total",
+		Help: "Total number of job_queue rows already claimed by another scan",
+	})
+
+	PickerOwnedShards = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "picker_owned_shards",
+		Help: "Number of shards currently assigned to this picker",
+	})
 )
 
 // Worker Metrics
@@ -80,7 +107,7 @@ var (
 	JobsExecutedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "jobs_executed_total",
 		Help: "Total number of jobs executed",
-	}, []string{"status"}) // success, failed
+	}, []string{"status"}) // success, failed, timed_out, cancelled, interrupted
 
 	JobExecutionDuration = promauto.NewHistogram(prometheus.HistogramOpts{
 		Name: "job_execution_duration_seconds",
@@ -91,6 +118,61 @@ var (
 		Name: "s3_operations_total",
 		Help: "Total number of S3 operations",
 	}, []string{"operation"}) // upload, download
+
+	JobRetriesTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "job_retries_total",
+		Help: "Total number of failed runs re-enqueued for retry",
+	})
+
+	ScheduleToDispatchSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "job_schedule_to_dispatch_seconds",
+		Help:    "Delay between a run's scheduled fire time and the picker sending it to SQS",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60, 300},
+	}, []string{"project_id"})
+
+	DispatchToStartSeconds = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "job_dispatch_to_start_seconds",
+		Help:    "Delay between the picker sending a run to SQS and a worker starting it",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2, 5, 10, 30, 60, 300},
+	}, []string{"project_id"})
+
+	MisfiredOccurrencesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "job_misfired_occurrences_total",
+		Help: "Total number of recurring occurrences dropped by the misfire policy",
+	}, []string{"policy"})
+
+	WorkerActiveJobs = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "worker_active_jobs",
+		Help: "Number of jobs currently executing on this worker",
+	})
+
+	VisibilityExtensionsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sqs_visibility_extensions_total",
+		Help: "Total number of SQS visibility timeout extensions",
+	}, []string{"result"}) // ok, error
+)
+
+// Relay Metrics
+var (
+	OutboxPublishedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "outbox_published_total",
+		Help: "Total number of submission events published from the outbox",
+	}, []string{"path"}) // inline, relay
+
+	OutboxPublishErrors = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_publish_errors_total",
+		Help: "Total number of failed relay publish attempts",
+	})
+
+	OutboxFailedTotal = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "outbox_failed_total",
+		Help: "Total number of submissions given up on and marked FAILED",
+	})
+
+	OutboxPendingEntries = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "outbox_pending_entries",
+		Help: "PENDING outbox rows seen in the last relay sweep",
+	})
 )
 
 // InitMetrics starts the Prometheus metrics server
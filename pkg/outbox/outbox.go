@@ -0,0 +1,60 @@
+package outbox
+
+import (
+	"hash/fnv"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// NumBuckets is the number of submission_outbox partitions. Kept small so
+// the relay can sweep all of them every poll.
+const NumBuckets = 16
+
+// StatusPending is the status entries are written with. They are deleted
+// once published or given up on.
+const StatusPending = "PENDING"
+
+// Entry is one submission event waiting to be published to Kafka.
+type Entry struct {
+	Bucket    int
+	CreatedAt time.Time
+	JobID     string
+	Event     []byte
+	Attempts  int
+	LastError string
+}
+
+// Bucket picks the outbox partition for a job.
+func Bucket(jobID string) int {
+	h := fnv.New32a()
+	h.Write([]byte(jobID))
+	return int(h.Sum32() % NumBuckets)
+}
+
+// NewEntry builds the PENDING row written alongside the job.
+func NewEntry(jobID string, createdAt time.Time, event []byte) Entry {
+	return Entry{Bucket: Bucket(jobID), CreatedAt: createdAt, JobID: jobID, Event: event}
+}
+
+// InsertStatement adds an entry as PENDING; bind it with Entry.InsertValues,
+// typically inside the batch that creates the job.
+const InsertStatement = `INSERT INTO submission_outbox (bucket, created_at, job_id, event, status, attempts) VALUES (?, ?, ?, ?, ?, ?)`
+
+func (e Entry) InsertValues() []interface{} {
+	return []interface{}{e.Bucket, e.CreatedAt, e.JobID, string(e.Event), StatusPending, e.Attempts}
+}
+
+// Delete removes an entry that was published or given up on. Keeping it,
+// even with a TTL, would have the relay re-read it on every sweep.
+func Delete(session *gocql.Session, e Entry) error {
+	return session.Query(`DELETE FROM submission_outbox WHERE bucket = ? AND created_at = ? AND job_id = ?`,
+		e.Bucket, e.CreatedAt, e.JobID).Exec()
+}
+
+// RecordAttempt bumps the attempt counter of a row that is still PENDING.
+func RecordAttempt(session *gocql.Session, e Entry, cause error) error {
+	return session.Query(`UPDATE submission_outbox SET attempts = ?, last_error = ? WHERE bucket = ? AND created_at = ? AND job_id = ?`,
+		e.Attempts+1, cause.Error(), e.Bucket, e.CreatedAt, e.JobID).Exec()
+}
+
@@ -0,0 +1,81 @@
+package schedule
+
+import (
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Parser accepts a leading seconds field as well as descriptors like "@every 5m".
+var Parser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Misfire policies decide what happens to occurrences that passed while a
+// previous run was still executing, the job was paused, or nothing was running.
+const (
+	// MisfireFireOnce runs once for all missed occurrences, then resumes the schedule.
+	MisfireFireOnce = "fire_once"
+	// MisfireFireAll runs every missed occurrence back to back (up to max_catchup).
+	MisfireFireAll = "fire_all"
+	// MisfireSkip drops missed occurrences and waits for the next future one.
+	MisfireSkip = "skip"
+)
+
+// DefaultMisfirePolicy applies to jobs that don't set one.
+const DefaultMisfirePolicy = MisfireFireOnce
+
+// Bounds the walk over missed occurrences, e.g. a per-second schedule that
+// was paused for weeks. Past this the schedule restarts from now.
+const maxMissedScan = 100000
+
+func ValidMisfirePolicy(policy string) bool {
+	switch policy {
+	case "", MisfireFireOnce, MisfireFireAll, MisfireSkip:
+		return true
+	}
+	return false
+}
+
+func Parse(spec string) (cron.Schedule, error) {
+	return Parser.Parse(spec)
+}
+
+// NextFire returns the occurrence to queue after the one scheduled for prev,
+// evaluated at now. Anchoring on prev rather than now keeps the schedule
+// from drifting when runs take a while. If occurrences between prev and now
+// were missed, policy decides which one comes next: it may be <= now, in
+// which case it is due immediately. maxCatchup caps how many missed
+// occurrences fire_all replays (0 means no cap). skipped is the number of
+// occurrences that will never run.
+func NextFire(s cron.Schedule, prev, now time.Time, policy string, maxCatchup int) (next time.Time, skipped int) {
+	next = s.Next(prev)
+	if next.After(now) || next.IsZero() {
+		return next, 0
+	}
+
+	// Collect missed occurrences; for fire_all only the last maxCatchup matter
+	keep := 1
+	if policy == MisfireFireAll {
+		keep = maxCatchup
+	}
+	var window []time.Time
+	missed := 0
+	for t := next; !t.IsZero() && !t.After(now); t = s.Next(t) {
+		missed++
+		if missed > maxMissedScan {
+			return s.Next(now), missed
+		}
+		if keep > 0 && len(window) == keep {
+			window = window[1:]
+		}
+		window = append(window, t)
+	}
+
+	switch policy {
+	case MisfireSkip:
+		return s.Next(now), missed
+	case MisfireFireAll:
+		return window[0], missed - len(window)
+	default: // fire_once: run the latest missed occurrence now
+		return window[len(window)-1], missed - 1
+	}
+}
@@ -0,0 +1,93 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+var base = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+func mustParse(t *testing.T, spec string) cron.Schedule {
+	t.Helper()
+	s, err := Parse(spec)
+	if err != nil {
+		t.Fatalf("parse %q: %v", spec, err)
+	}
+	return s
+}
+
+func TestNextFireAnchorsOnPreviousOccurrence(t *testing.T) {
+	s := mustParse(t, "0 * * * * *") // every minute
+	// The 12:00 run finished 20s late; the next fire stays on the minute
+	next, skipped := NextFire(s, base, base.Add(20*time.Second), DefaultMisfirePolicy, 0)
+	if want := base.Add(time.Minute); !next.Equal(want) || skipped != 0 {
+		t.Errorf("got %v (skipped %d), want %v", next, skipped, want)
+	}
+}
+
+func TestNextFireMisfirePolicies(t *testing.T) {
+	s := mustParse(t, "0 * * * * *")
+	// The 12:00 run took 3m30s: 12:01, 12:02 and 12:03 were missed
+	now := base.Add(3*time.Minute + 30*time.Second)
+
+	cases := []struct {
+		policy     string
+		maxCatchup int
+		want       time.Time
+		skipped    int
+	}{
+		{MisfireFireOnce, 0, base.Add(3 * time.Minute), 2},
+		{"", 0, base.Add(3 * time.Minute), 2},
+		{MisfireFireAll, 0, base.Add(1 * time.Minute), 0},
+		{MisfireFireAll, 2, base.Add(2 * time.Minute), 1},
+		{MisfireSkip, 0, base.Add(4 * time.Minute), 3},
+	}
+	for _, c := range cases {
+		next, skipped := NextFire(s, base, now, c.policy, c.maxCatchup)
+		if !next.Equal(c.want) || skipped != c.skipped {
+			t.Errorf("%q max_catchup=%d: got %v (skipped %d), want %v (skipped %d)",
+				c.policy, c.maxCatchup, next.Format("15:04"), skipped, c.want.Format("15:04"), c.skipped)
+		}
+	}
+}
+
+func TestNextFireCatchesUpOneOccurrenceAtATime(t *testing.T) {
+	s := mustParse(t, "@every 10s")
+	now := base.Add(35 * time.Second)
+
+	var fired []time.Time
+	prev := base
+	for i := 0; i < 10; i++ {
+		next, _ := NextFire(s, prev, now, MisfireFireAll, 0)
+		if next.After(now) {
+			break
+		}
+		fired = append(fired, next)
+		prev = next
+	}
+	if len(fired) != 3 {
+		t.Fatalf("replayed %d occurrences, want 3: %v", len(fired), fired)
+	}
+}
+
+func TestNextFireBoundsLongOutages(t *testing.T) {
+	s := mustParse(t, "* * * * * *") // every second, down for two days
+	now := base.Add(48 * time.Hour)
+	next, _ := NextFire(s, base, now, MisfireFireAll, 0)
+	if next.Before(now) {
+		t.Errorf("expected the schedule to restart from now after a huge gap, got %v", next)
+	}
+}
+
+func TestValidMisfirePolicy(t *testing.T) {
+	for _, p := range []string{"", MisfireFireOnce, MisfireFireAll, MisfireSkip} {
+		if !ValidMisfirePolicy(p) {
+			t.Errorf("%q rejected", p)
+		}
+	}
+	if ValidMisfirePolicy("fire_twice") {
+		t.Error("unknown policy accepted")
+	}
+}
@@ -0,0 +1,114 @@
+package schedule
+
+import (
+	"errors"
+	"time"
+	_ "time/tzdata" // IANA database for images without /usr/share/zoneinfo
+
+	"github.com/robfig/cron/v3"
+)
+
+// DefaultTimezone is used for jobs that don't set one.
+const DefaultTimezone = "UTC"
+
+// Set by the parser on fields given as "*" (cron's starBit).
+const wildcardBit = 1 << 63
+
+// LoadLocation resolves a job's timezone. Empty means DefaultTimezone;
+// "Local" is rejected because it would depend on the host running the job.
+func LoadLocation(tz string) (*time.Location, error) {
+	if tz == "" {
+		tz = DefaultTimezone
+	}
+	if tz == "Local" {
+		return nil, errors.New(`"Local" is not allowed; use an IANA name such as Europe/Berlin`)
+	}
+	return time.LoadLocation(tz)
+}
+
+// ParseIn parses spec and evaluates it in the IANA zone tz.
+//
+// Daylight saving transitions follow the usual cron convention:
+//   - Schedules with a specific hour (e.g. "0 0 9 * * 1-5", @daily) are wall
+//     clock times. An occurrence that falls in a spring-forward gap runs once
+//     at the end of the gap (02:30 becomes 03:00); several occurrences in the
+//     same gap collapse into that single run. In a fall-back overlap the
+//     occurrence runs once, on the first pass through the repeated hour.
+//   - Schedules with "*" in the hour field (e.g. every 15 minutes, @hourly)
+//     follow elapsed time: nothing fires in the hour skipped by a gap, and
+//     the repeated hour of an overlap fires again.
+//   - @every intervals ignore the zone entirely.
+func ParseIn(spec, tz string) (cron.Schedule, error) {
+	loc, err := LoadLocation(tz)
+	if err != nil {
+		return nil, err
+	}
+	sched, err := Parser.Parse(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := sched.(*cron.SpecSchedule)
+	if !ok {
+		return sched, nil
+	}
+	if s.Hour&wildcardBit != 0 {
+		s.Location = loc
+		return s, nil
+	}
+	s.Location = time.UTC
+	return &wallClockSchedule{spec: s, loc: loc}, nil
+}
+
+// wallClockSchedule evaluates a spec against local wall-clock readings and
+// maps the result back onto real time, resolving gaps and overlaps.
+type wallClockSchedule struct {
+	spec *cron.SpecSchedule // evaluated in UTC, which has no transitions
+	loc  *time.Location
+}
+
+func (w *wallClockSchedule) Next(t time.Time) time.Time {
+	wall := toWall(t.In(w.loc))
+	for {
+		wall = w.spec.Next(wall)
+		if wall.IsZero() {
+			return wall
+		}
+		// During an overlap t may already be past the first pass of this
+		// wall time; that occurrence has run, so look for the next one.
+		if next := fromWall(wall, w.loc); next.After(t) {
+			return next.In(t.Location())
+		}
+	}
+}
+
+// toWall re-labels the wall-clock reading of t as UTC.
+func toWall(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+// fromWall finds the real instant at which loc's clocks read wall. Readings
+// skipped by a gap map to the end of the gap; readings repeated by an
+// overlap map to their first occurrence.
+func fromWall(wall time.Time, loc *time.Location) time.Time {
+	t := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), wall.Second(), wall.Nanosecond(), loc)
+	if !toWall(t).Equal(wall) {
+		// In a gap; time.Date normalised past it, so its zone starts at the gap's end
+		start, _ := t.ZoneBounds()
+		return start
+	}
+
+	// time.Date picks the later of two matching instants; prefer the earlier one
+	start, _ := t.ZoneBounds()
+	if start.IsZero() {
+		return t
+	}
+	_, before := start.Add(-time.Nanosecond).Zone()
+	_, after := t.Zone()
+	if shift := time.Duration(before-after) * time.Second; shift > 0 {
+		if earlier := t.Add(-shift); earlier.Before(start) && toWall(earlier.In(loc)).Equal(wall) {
+			return earlier
+		}
+	}
+	return t
+}
@@ -0,0 +1,124 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func mustParseIn(t *testing.T, spec, tz string) interface{ Next(time.Time) time.Time } {
+	t.Helper()
+	s, err := ParseIn(spec, tz)
+	if err != nil {
+		t.Fatalf("parse %q in %s: %v", spec, tz, err)
+	}
+	return s
+}
+
+func at(t *testing.T, tz, value string) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return v
+}
+
+// fires lists the first n occurrences after from.
+func fires(s interface{ Next(time.Time) time.Time }, from time.Time, n int) []time.Time {
+	var out []time.Time
+	for t := from; len(out) < n; {
+		t = s.Next(t)
+		out = append(out, t)
+	}
+	return out
+}
+
+func TestParseInEvaluatesInZone(t *testing.T) {
+	s := mustParseIn(t, "0 0 9 * * 1-5", "Europe/Berlin")
+	// Friday 2026-01-09 10:00 Berlin; next weekday 09:00 is Monday
+	next := s.Next(at(t, "Europe/Berlin", "2026-01-09 10:00"))
+	if want := at(t, "Europe/Berlin", "2026-01-12 09:00"); !next.Equal(want) {
+		t.Errorf("got %v, want %v", next, want)
+	}
+	if next.UTC().Hour() != 8 {
+		t.Errorf("09:00 CET should be 08:00 UTC, got %v", next.UTC())
+	}
+}
+
+func TestSpringForwardGapRunsAtEndOfGap(t *testing.T) {
+	// Berlin skips 02:00-03:00 on 2026-03-29
+	s := mustParseIn(t, "0 30 2 * * *", "Europe/Berlin")
+	got := fires(s, at(t, "Europe/Berlin", "2026-03-28 12:00"), 3)
+	want := []time.Time{
+		at(t, "Europe/Berlin", "2026-03-29 03:00"), // 02:30 doesn't exist
+		at(t, "Europe/Berlin", "2026-03-30 02:30"),
+		at(t, "Europe/Berlin", "2026-03-31 02:30"),
+	}
+	for i := range want {
+		if !got[i].Equal(want[i]) {
+			t.Errorf("fire %d: got %v, want %v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFallBackOverlapRunsOnce(t *testing.T) {
+	// Berlin repeats 02:00-03:00 on 2026-10-25
+	s := mustParseIn(t, "0 30 2 * * *", "Europe/Berlin")
+	got := fires(s, at(t, "Europe/Berlin", "2026-10-24 12:00"), 2)
+
+	first := time.Date(2026, 10, 25, 0, 30, 0, 0, time.UTC) // 02:30 CEST, first pass
+	if !got[0].Equal(first) {
+		t.Errorf("got %v, want the first 02:30 (%v)", got[0], first)
+	}
+	if want := at(t, "Europe/Berlin", "2026-10-26 02:30"); !got[1].Equal(want) {
+		t.Errorf("overlap fired twice: second fire %v, want %v", got[1], want)
+	}
+
+	// Anchored on the second pass, the 02:30 of that day has already run
+	secondPass := time.Date(2026, 10, 25, 1, 10, 0, 0, time.UTC) // 02:10 CET
+	if next := s.Next(secondPass); !next.Equal(at(t, "Europe/Berlin", "2026-10-26 02:30")) {
+		t.Errorf("got %v, want next day's 02:30", next)
+	}
+}
+
+func TestWildcardHourFollowsElapsedTime(t *testing.T) {
+	s := mustParseIn(t, "0 30 * * * *", "Europe/Berlin")
+
+	// Spring forward: 01:30 CET is followed by 03:30 CEST, one hour later
+	gap := fires(s, at(t, "Europe/Berlin", "2026-03-29 01:00"), 2)
+	if d := gap[1].Sub(gap[0]); d != time.Hour {
+		t.Errorf("across the gap fires were %v apart, want 1h (%v, %v)", d, gap[0], gap[1])
+	}
+
+	// Fall back: 02:30 happens twice, an hour apart
+	overlap := fires(s, time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC), 3) // from 02:00 CEST
+	for i := 1; i < len(overlap); i++ {
+		if d := overlap[i].Sub(overlap[i-1]); d != time.Hour {
+			t.Errorf("fires %v and %v are %v apart, want 1h", overlap[i-1], overlap[i], d)
+		}
+	}
+	berlin := at(t, "Europe/Berlin", "2026-10-25 00:00").Location()
+	if overlap[0].In(berlin).Hour() != 2 || overlap[1].In(berlin).Hour() != 2 {
+		t.Errorf("expected 02:30 CEST then 02:30 CET, got %v, %v", overlap[0].In(berlin), overlap[1].In(berlin))
+	}
+}
+
+func TestLoadLocation(t *testing.T) {
+	if loc, err := LoadLocation(""); err != nil || loc.String() != "UTC" {
+		t.Errorf("empty timezone: got %v, %v", loc, err)
+	}
+	for _, tz := range []string{"America/New_York", "Asia/Kolkata", "Australia/Lord_Howe"} {
+		if _, err := LoadLocation(tz); err != nil {
+			t.Errorf("%s: %v", tz, err)
+		}
+	}
+	for _, tz := range []string{"Local", "Mars/Olympus_Mons", "CEST"} {
+		if _, err := LoadLocation(tz); err == nil {
+			t.Errorf("%s accepted", tz)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package sharding
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+)
+
+// NumShards is the number of job_queue partitions the schedule is split into.
+const NumShards = 1024
+
+// Etcd key layout shared by the coordinator and the pickers.
+const (
+	PickersPrefix     = "/scheduler/pickers/"
+	AssignmentsPrefix = "/scheduler/assignments/"
+)
+
+// Assignment is the value stored under AssignmentsPrefix + pickerID.
+type Assignment struct {
+	Shards []int `json:"shards"`
+}
+
+func PickerKey(pickerID string) string {
+	return PickersPrefix + pickerID
+}
+
+func AssignmentKey(pickerID string) string {
+	return AssignmentsPrefix + pickerID
+}
+
+// IDFromKey strips the given prefix from an etcd key, returning the picker ID.
+func IDFromKey(prefix, key string) string {
+	return strings.TrimPrefix(key, prefix)
+}
+
+// Distribute splits all shards into contiguous ranges, one per picker.
+// Pickers are sorted first so every coordinator computes the same layout.
+func Distribute(pickers []string) map[string][]int {
+	ids := append([]string(nil), pickers...)
+	sort.Strings(ids)
+
+	result := make(map[string][]int, len(ids))
+	if len(ids) == 0 {
+		return result
+	}
+
+	base := NumShards / len(ids)
+	extra := NumShards % len(ids)
+	next := 0
+	for i, id := range ids {
+		size := base
+		if i < extra {
+			size++
+		}
+		shards := make([]int, 0, size)
+		for s := next; s < next+size; s++ {
+			shards = append(shards, s)
+		}
+		result[id] = shards
+		next += size
+	}
+	return result
+}
+
+// Intersect returns the shards present in both a and b, preserving a's order.
+func Intersect(a, b []int) []int {
+	inB := make(map[int]bool, len(b))
+	for _, s := range b {
+		inB[s] = true
+	}
+	out := make([]int, 0, len(a))
+	for _, s := range a {
+		if inB[s] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// Equal reports whether two shard lists contain the same shards in the same order.
+func Equal(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func Encode(shards []int) (string, error) {
+	if shards == nil {
+		shards = []int{}
+	}
+	b, err := json.Marshal(Assignment{Shards: shards})
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func Decode(value []byte) ([]int, error) {
+	var a Assignment
+	if err := json.Unmarshal(value, &a); err != nil {
+		return nil, err
+	}
+	return a.Shards, nil
+}
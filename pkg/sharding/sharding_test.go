@@ -0,0 +1,36 @@
+package sharding
+
+import "testing"
+
+func TestDistributeCoversEveryShardOnce(t *testing.T) {
+	for _, pickers := range [][]string{{"a"}, {"b", "a", "c"}, {"p1", "p2", "p3", "p4", "p5", "p6", "p7"}} {
+		owners := make(map[int]string)
+		for id, shards := range Distribute(pickers) {
+			for _, s := range shards {
+				if prev, dup := owners[s]; dup {
+					t.Fatalf("shard %d assigned to both %s and %s", s, prev, id)
+				}
+				owners[s] = id
+			}
+		}
+		if len(owners) != NumShards {
+			t.Errorf("%d pickers: %d shards assigned, want %d", len(pickers), len(owners), NumShards)
+		}
+	}
+}
+
+func TestDistributeIsOrderIndependent(t *testing.T) {
+	a := Distribute([]string{"x", "y", "z"})
+	b := Distribute([]string{"z", "x", "y"})
+	for id := range a {
+		if !Equal(a[id], b[id]) {
+			t.Errorf("picker %s got different shards depending on input order", id)
+		}
+	}
+}
+
+func TestDistributeNoPickers(t *testing.T) {
+	if got := Distribute(nil); len(got) != 0 {
+		t.Errorf("expected empty layout, got %v", got)
+	}
+}
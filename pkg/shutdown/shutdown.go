@@ -0,0 +1,65 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+)
+
+// DefaultGracePeriod leaves headroom under the 30s stop_grace_period in docker-compose.yml.
+const DefaultGracePeriod = 25 * time.Second
+
+// NotifyContext returns a context cancelled on SIGINT or SIGTERM. Services use
+// it to stop taking new work; in-flight work gets GracePeriod to finish.
+func NotifyContext() (context.Context, context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+// GracePeriod reads SHUTDOWN_GRACE_PERIOD, falling back to DefaultGracePeriod.
+func GracePeriod() time.Duration {
+	return GracePeriodOr(DefaultGracePeriod)
+}
+
+// GracePeriodOr reads SHUTDOWN_GRACE_PERIOD, falling back to def. Services
+// given a longer stop_grace_period pass their own default.
+func GracePeriodOr(def time.Duration) time.Duration {
+	if v := os.Getenv("SHUTDOWN_GRACE_PERIOD"); v != "" {
+		if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+			return d
+		}
+		log.Printf("Invalid SHUTDOWN_GRACE_PERIOD %q, using %v", v, def)
+	}
+	return def
+}
+
+// ListenAndServe runs srv in the background. Once ctx is cancelled the server
+// stops accepting connections and waits up to grace for open requests.
+// The returned channel is closed after the server has fully stopped.
+func ListenAndServe(ctx context.Context, srv *http.Server, grace time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+
+	go func() {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Fatalf("Server %s failed: %v", srv.Addr, err)
+		}
+	}()
+
+	// ListenAndServe returns as soon as Shutdown starts, so completion is
+	// signalled from here, once open requests have drained.
+	go func() {
+		defer close(done)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), grace)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Server %s did not shut down cleanly: %v", srv.Addr, err)
+		}
+	}()
+
+	return done
+}
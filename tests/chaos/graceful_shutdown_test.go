@@ -0,0 +1,52 @@
+package chaos
+
+import (
+    "os/exec"
+    "testing"
+    "time"
+)
+
+func TestWorkerGracefulShutdown(t *testing.T) {
+    // 1. One job that finishes inside the 25s grace period, one that doesn't
+    shortJob := submitJob(t, "chaos-test", "sleep:5s", "", "")
+    longJob := submitJob(t, "chaos-test", "sleep:60s", "", "")
+    t.Logf("Submitted short job %s and long job %s", shortJob, longJob)
+
+    // 2. Wait for both to be picked up
+    time.Sleep(3 * time.Second)
+
+    // 3. SIGTERM the worker; docker waits up to stop_grace_period before SIGKILL
+    t.Log("Stopping scheduler-worker container (SIGTERM)...")
+    if err := exec.Command("docker", "stop", "scheduler-worker").Run(); err != nil {
+        t.Fatalf("Failed to stop worker: %v", err)
+    }
+
+    // 4. The short job drained, the long one was handed back with its partial run recorded
+    if status := runStatus(t, shortJob); status != "COMPLETED" {
+        t.Fatalf("Short job should have finished during drain, got status %q", status)
+    }
+    if status := runStatus(t, longJob); status != "INTERRUPTED" {
+        t.Fatalf("Long job should have been recorded as INTERRUPTED, got status %q", status)
+    }
+
+    // 5. Restart Worker; the message is visible again immediately
+    t.Log("Restarting scheduler-worker container...")
+    if err := exec.Command("docker", "start", "scheduler-worker").Run(); err != nil {
+        t.Fatalf("Failed to start worker: %v", err)
+    }
+
+    // 6. No visibility timeout to wait out: 60s sleep + startup
+    t.Log("Waiting for long job to be re-run...")
+    waitForJobCompletion(t, longJob, 90*time.Second)
+
+    t.Log("Interrupted job re-ran and completed successfully!")
+}
+
+func runStatus(t *testing.T, jobID string) string {
+    var status string
+    err := scyllaClient.Session.Query(`SELECT status FROM job_runs WHERE job_id = ? LIMIT 1 ALLOW FILTERING`, jobID).Scan(&status)
+    if err != nil {
+        t.Fatalf("Failed to read run of job %s: %v", jobID, err)
+    }
+    return status
+}
@@ -0,0 +1,87 @@
+package chaos
+
+import (
+    "encoding/json"
+    "os/exec"
+    "testing"
+    "time"
+
+    "distributed_job_scheduler/pkg/outbox"
+)
+
+func TestSubmitDuringKafkaOutage(t *testing.T) {
+    // 1. Take Kafka away
+    t.Log("Stopping scheduler-kafka container...")
+    if err := exec.Command("docker", "stop", "scheduler-kafka").Run(); err != nil {
+        t.Fatalf("Failed to stop kafka: %v", err)
+    }
+    defer exec.Command("docker", "start", "scheduler-kafka").Run()
+
+    // 2. Submission still succeeds; the event waits in the outbox
+    jobID := submitJob(t, "chaos-test", "echo:test-kafka-outage", "", "")
+    t.Logf("Submitted job %s with Kafka down", jobID)
+
+    time.Sleep(5 * time.Second)
+    if hasRun(t, jobID) {
+        t.Fatal("Job executed while Kafka was down!")
+    }
+
+    // 3. Bring Kafka back
+    t.Log("Restarting scheduler-kafka container...")
+    if err := exec.Command("docker", "start", "scheduler-kafka").Run(); err != nil {
+        t.Fatalf("Failed to start kafka: %v", err)
+    }
+
+    // 4. The relay publishes it; broker startup + min age + relay sweep
+    t.Log("Waiting for relay to publish and job to complete...")
+    waitForJobCompletion(t, jobID, 120*time.Second)
+
+    // 5. A failed outbox delete makes the relay publish the event again after
+    // the run; the writer re-queues the job, which must not run twice
+    t.Log("Re-publishing the submission through the outbox...")
+    republishSubmission(t, jobID)
+    time.Sleep(10 * time.Second)
+    if n := countRuns(t, jobID); n != 1 {
+        t.Fatalf("Expected 1 run after the duplicate submission event, got %d", n)
+    }
+
+    t.Log("Job submitted during Kafka outage completed successfully!")
+}
+
+// republishSubmission puts the job's submission event back in the outbox,
+// old enough for the relay's next sweep, and waits until it is published.
+func republishSubmission(t *testing.T, jobID string) {
+    var shardID int
+    var nextFireAt time.Time
+    if err := scyllaClient.Session.Query(`SELECT shard_id, next_fire_at FROM jobs WHERE job_id = ?`, jobID).Scan(&shardID, &nextFireAt); err != nil {
+        t.Fatalf("Failed to load job %s: %v", jobID, err)
+    }
+    event, _ := json.Marshal(map[string]interface{}{
+        "job_id":       jobID,
+        "next_fire_at": nextFireAt.Format(time.RFC3339Nano),
+        "shard_id":     shardID,
+    })
+    entry := outbox.NewEntry(jobID, time.Now().Add(-time.Minute), event)
+    if err := scyllaClient.Session.Query(outbox.InsertStatement, entry.InsertValues()...).Exec(); err != nil {
+        t.Fatalf("Failed to insert outbox entry: %v", err)
+    }
+
+    query := `SELECT job_id FROM submission_outbox WHERE bucket = ? AND created_at = ? AND job_id = ?`
+    deadline := time.Now().Add(30 * time.Second)
+    for time.Now().Before(deadline) {
+        iter := scyllaClient.Session.Query(query, entry.Bucket, entry.CreatedAt, jobID).Iter()
+        n := iter.NumRows()
+        iter.Close()
+        if n == 0 {
+            return
+        }
+        time.Sleep(500 * time.Millisecond)
+    }
+    t.Fatalf("Relay did not publish the re-inserted entry of job %s", jobID)
+}
+
+func countRuns(t *testing.T, jobID string) int {
+    iter := scyllaClient.Session.Query(`SELECT run_id FROM job_runs WHERE job_id = ?`, jobID).Iter()
+    defer iter.Close()
+    return iter.NumRows()
+}
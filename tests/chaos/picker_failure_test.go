@@ -9,7 +9,7 @@ import (
 func TestPickerFailure(t *testing.T) {
     // 1. Submit scheduled job for 10s later
     fireAt := time.Now().Add(10 * time.Second)
-    payload := "test-picker-recovery"
+    payload := "echo:test-picker-recovery"
     
     // We reuse submitJob from worker_failure_test.go (it's in the same package 'chaos')
     jobID := submitJob(t, "chaos-test", payload, "", fireAt.Format(time.RFC3339))
@@ -0,0 +1,41 @@
+package integration
+
+import (
+    "net/http"
+    "testing"
+    "time"
+)
+
+func TestStructuredCommandSpec(t *testing.T) {
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id": "command-spec-test",
+        "job_type":   "cmd",
+        "payload":    `{"argv": ["sh", "-c", "echo \"$GREETING $1\" && pwd && cat", "sh", "it's quoted; no shell"], "env": {"GREETING": "hello"}, "cwd": "/tmp", "stdin": "from stdin"}`,
+    })
+    waitForJobCompletion(t, jobID, 20*time.Second)
+
+    var page runsPage
+    if code := getJSON(t, "/job/"+jobID+"/runs", &page); code != http.StatusOK || len(page.Runs) == 0 {
+        t.Fatalf("list runs: status %d", code)
+    }
+    var run runRecord
+    getJSON(t, "/job/"+jobID+"/runs/"+page.Runs[0].RunID, &run)
+
+    if want := "hello it's quoted; no shell\n/tmp\nfrom stdin"; run.Output == nil || *run.Output != want {
+        t.Errorf("output %v, want %q", run.Output, want)
+    }
+    if run.ExitCode == nil || *run.ExitCode != 0 {
+        t.Errorf("exit_code %v, want 0", run.ExitCode)
+    }
+}
+
+func TestInvalidCommandSpecRejected(t *testing.T) {
+    code, body := submitJobStatus(t, map[string]interface{}{
+        "project_id": "command-spec-test",
+        "job_type":   "cmd",
+        "payload":    `{"argv": ["ls"], "cwd": "relative/dir"}`,
+    })
+    if code != http.StatusBadRequest {
+        t.Errorf("expected 400, got %d: %s", code, body)
+    }
+}
@@ -0,0 +1,63 @@
+package integration
+
+import (
+    "testing"
+    "time"
+
+    "github.com/gocql/gocql"
+)
+
+// queueStaleClaim replaces the job's queue row with one that looks claimed
+// by a picker that is gone (or still working, if leaseUntil is in the future).
+func queueStaleClaim(t *testing.T, jobID string, leaseUntil time.Time) gocql.UUID {
+    var shardID int
+    var nextFireAt time.Time
+    if err := scyllaClient.Session.Query(`SELECT shard_id, next_fire_at FROM jobs WHERE job_id = ?`, jobID).Scan(&shardID, &nextFireAt); err != nil {
+        t.Fatalf("Failed to load job %s: %v", jobID, err)
+    }
+    if err := scyllaClient.Session.Query(`DELETE FROM job_queue WHERE shard_id = ? AND next_fire_at = ? AND job_id = ?`, shardID, nextFireAt, jobID).Exec(); err != nil {
+        t.Fatalf("Failed to remove queue row: %v", err)
+    }
+
+    runID := gocql.TimeUUID()
+    query := `INSERT INTO job_queue (shard_id, next_fire_at, job_id, status, owner, lease_until, run_id) VALUES (?, ?, ?, ?, ?, ?, ?)`
+    if err := scyllaClient.Session.Query(query, shardID, time.Now().Add(-time.Second), jobID, "DISPATCHING", "dead-picker", leaseUntil, runID).Exec(); err != nil {
+        t.Fatalf("Failed to insert claimed queue row: %v", err)
+    }
+    return runID
+}
+
+func TestStaleClaimIsRedispatched(t *testing.T) {
+    // Far-future job so the writer's own row never fires during the test
+    jobID := submitJob(t, "claim-test", "echo:ok", "", time.Now().Add(time.Hour).Format(time.RFC3339))
+    time.Sleep(2 * time.Second) // let the writer insert the queue row
+
+    runID := queueStaleClaim(t, jobID, time.Now().Add(-time.Minute))
+
+    // Re-dispatched under the run_id recorded with the claim
+    deadline := time.Now().Add(30 * time.Second)
+    for time.Now().Before(deadline) {
+        var status string
+        err := scyllaClient.Session.Query(`SELECT status FROM job_runs WHERE job_id = ? AND run_id = ?`, jobID, runID).Scan(&status)
+        if err == nil && status == "COMPLETED" {
+            return
+        }
+        time.Sleep(500 * time.Millisecond)
+    }
+    t.Fatalf("Stale claim on job %s was not re-dispatched as run %s", jobID, runID)
+}
+
+func TestLiveClaimIsNotDispatchedTwice(t *testing.T) {
+    jobID := submitJob(t, "claim-test", "echo:ok", "", time.Now().Add(time.Hour).Format(time.RFC3339))
+    time.Sleep(2 * time.Second)
+
+    // Another picker holds a valid lease; nobody else may send the row
+    queueStaleClaim(t, jobID, time.Now().Add(time.Minute))
+
+    time.Sleep(5 * time.Second)
+    iter := scyllaClient.Session.Query(`SELECT status FROM job_runs WHERE job_id = ?`, jobID).Iter()
+    defer iter.Close()
+    if iter.NumRows() > 0 {
+        t.Fatalf("Job %s was dispatched while another picker held the claim", jobID)
+    }
+}
@@ -0,0 +1,71 @@
+package integration
+
+import (
+    "encoding/json"
+    "net/http"
+    "strings"
+    "testing"
+
+    "github.com/google/uuid"
+)
+
+func postWithIdempotencyKey(t *testing.T, key, body string) (*http.Response, map[string]string) {
+    req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/submit", strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("X-User-ID", "idempotency-test-user")
+    req.Header.Set("Idempotency-Key", key)
+
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatalf("Failed to submit job: %v", err)
+    }
+    defer resp.Body.Close()
+
+    result := map[string]string{}
+    json.NewDecoder(resp.Body).Decode(&result)
+    return resp, result
+}
+
+func TestIdempotentSubmission(t *testing.T) {
+    key := uuid.New().String()
+    body := `{"project_id": "idempotency-test", "payload": "sleep:10ms"}`
+
+    first, firstResult := postWithIdempotencyKey(t, key, body)
+    if first.StatusCode != http.StatusCreated {
+        t.Fatalf("First submit: expected 201, got %d", first.StatusCode)
+    }
+
+    // Same key + same body (different formatting) replays the original response
+    retry, retryResult := postWithIdempotencyKey(t, key, `{"payload":"sleep:10ms","project_id":"idempotency-test"}`)
+    if retry.StatusCode != http.StatusCreated {
+        t.Errorf("Replay: expected 201, got %d", retry.StatusCode)
+    }
+    if retryResult["job_id"] != firstResult["job_id"] {
+        t.Errorf("Replay returned job %s, want original %s", retryResult["job_id"], firstResult["job_id"])
+    }
+    if retry.Header.Get("Idempotent-Replayed") != "true" {
+        t.Error("Expected Idempotent-Replayed header on replay")
+    }
+
+    // Same key + different body is a conflict
+    conflict, _ := postWithIdempotencyKey(t, key, `{"project_id": "idempotency-test", "payload": "sleep:20ms"}`)
+    if conflict.StatusCode != http.StatusConflict {
+        t.Errorf("Mismatched body: expected 409, got %d", conflict.StatusCode)
+    }
+}
+
+func TestIdempotencyKeyRequiresUser(t *testing.T) {
+    body := `{"project_id": "idempotency-test", "payload": "sleep:10ms"}`
+    req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/submit", strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("Idempotency-Key", uuid.New().String())
+
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatalf("Failed to submit job: %v", err)
+    }
+    resp.Body.Close()
+    if resp.StatusCode != http.StatusBadRequest {
+        t.Errorf("Idempotency-Key without X-User-ID: expected 400, got %d", resp.StatusCode)
+    }
+}
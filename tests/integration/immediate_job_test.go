@@ -7,7 +7,7 @@ import (
 
 func TestImmediateJobExecution(t *testing.T) {
     // 1. Submit Job
-    payload := "test-immediate-" + time.Now().Format(time.RFC3339)
+    payload := "echo:test-immediate-" + time.Now().Format(time.RFC3339)
     jobID := submitJob(t, "integration-test", payload, "", "")
 
     t.Logf("Submitted immediate job: %s", jobID)
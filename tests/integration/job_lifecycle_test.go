@@ -0,0 +1,139 @@
+package integration
+
+import (
+    "encoding/json"
+    "net/http"
+    "os/exec"
+    "testing"
+    "time"
+
+    "github.com/gocql/gocql"
+)
+
+func postJobAction(t *testing.T, jobID, action string) int {
+    req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/job/"+jobID+"/"+action, nil)
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatalf("Failed to %s job: %v", action, err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode == http.StatusOK {
+        var result map[string]string
+        json.NewDecoder(resp.Body).Decode(&result)
+        t.Logf("%s %s -> %s", action, jobID, result["status"])
+    }
+    return resp.StatusCode
+}
+
+func TestCancelRunningJob(t *testing.T) {
+    jobID := submitJob(t, "lifecycle-test", "sleep:30s", "", "")
+
+    // Wait until the worker has picked it up
+    time.Sleep(4 * time.Second)
+
+    if code := postJobAction(t, jobID, "cancel"); code != http.StatusOK {
+        t.Fatalf("Cancel: expected 200, got %d", code)
+    }
+
+    deadline := time.Now().Add(10 * time.Second)
+    for time.Now().Before(deadline) {
+        var status string
+        iter := scyllaClient.Session.Query(`SELECT status FROM job_runs WHERE job_id = ?`, jobID).Iter()
+        found := iter.Scan(&status)
+        iter.Close()
+        if found && status == "CANCELLED" {
+            break
+        }
+        time.Sleep(500 * time.Millisecond)
+    }
+
+    if status := GetJobStatus(t, jobID); status != "CANCELLED" {
+        t.Errorf("Expected job status CANCELLED, got %s", status)
+    }
+    if !hasRunWithStatus(t, jobID, "CANCELLED") {
+        t.Error("Expected the in-flight run to be recorded as CANCELLED well before its 30s sleep finished")
+    }
+
+    // Terminal: further transitions are rejected
+    if code := postJobAction(t, jobID, "resume"); code != http.StatusConflict {
+        t.Errorf("Resume after cancel: expected 409, got %d", code)
+    }
+}
+
+func TestPauseAndResumeScheduledJob(t *testing.T) {
+    fireAt := time.Now().Add(3 * time.Second)
+    jobID := submitJob(t, "lifecycle-test", "sleep:10ms", "", fireAt.Format(time.RFC3339))
+
+    // Give the writer time to queue it, then pause before it fires
+    time.Sleep(1 * time.Second)
+    if code := postJobAction(t, jobID, "pause"); code != http.StatusOK {
+        t.Fatalf("Pause: expected 200, got %d", code)
+    }
+
+    // Well past the fire time: nothing should have run
+    time.Sleep(5 * time.Second)
+    if hasRunWithStatus(t, jobID, "COMPLETED") {
+        t.Fatal("Paused job executed")
+    }
+
+    if code := postJobAction(t, jobID, "resume"); code != http.StatusOK {
+        t.Fatalf("Resume: expected 200, got %d", code)
+    }
+    waitForJobCompletion(t, jobID, 15*time.Second)
+}
+
+func TestResumeJobPausedWhileInSQS(t *testing.T) {
+    // With the worker stopped, the dispatched run waits in SQS
+    if err := exec.Command("docker", "stop", "scheduler-worker").Run(); err != nil {
+        t.Fatalf("Failed to stop worker: %v", err)
+    }
+    defer exec.Command("docker", "start", "scheduler-worker").Run()
+
+    jobID := submitJob(t, "lifecycle-test", "sleep:10ms", "", "")
+    waitForDispatchedRun(t, jobID, true, 20*time.Second)
+    if code := postJobAction(t, jobID, "pause"); code != http.StatusOK {
+        t.Fatalf("Pause: expected 200, got %d", code)
+    }
+
+    // The worker skips the run of the paused job and releases its dispatch
+    if err := exec.Command("docker", "start", "scheduler-worker").Run(); err != nil {
+        t.Fatalf("Failed to start worker: %v", err)
+    }
+    waitForDispatchedRun(t, jobID, false, 30*time.Second)
+    if hasRunWithStatus(t, jobID, "COMPLETED") {
+        t.Fatal("Paused job executed")
+    }
+
+    if code := postJobAction(t, jobID, "resume"); code != http.StatusOK {
+        t.Fatalf("Resume: expected 200, got %d", code)
+    }
+    waitForJobCompletion(t, jobID, 30*time.Second)
+}
+
+// waitForDispatchedRun waits until the picker has (or no longer has) a run
+// of the job's current occurrence on record.
+func waitForDispatchedRun(t *testing.T, jobID string, dispatched bool, timeout time.Duration) {
+    deadline := time.Now().Add(timeout)
+    for time.Now().Before(deadline) {
+        var runID gocql.UUID
+        err := scyllaClient.Session.Query(`SELECT dispatched_run_id FROM jobs WHERE job_id = ?`, jobID).Scan(&runID)
+        if err == nil && (runID != gocql.UUID{}) == dispatched {
+            return
+        }
+        time.Sleep(500 * time.Millisecond)
+    }
+    t.Fatalf("Job %s: dispatched run recorded should be %v within %v", jobID, dispatched, timeout)
+}
+
+func hasRunWithStatus(t *testing.T, jobID, want string) bool {
+    iter := scyllaClient.Session.Query(`SELECT status FROM job_runs WHERE job_id = ?`, jobID).Iter()
+    defer iter.Close()
+    var status string
+    for iter.Scan(&status) {
+        if status == want {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,162 @@
+package integration
+
+import (
+    "encoding/json"
+    "net/http"
+    "net/url"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+type jobsPage struct {
+    Jobs []struct {
+        JobID     string `json:"job_id"`
+        ProjectID string `json:"project_id"`
+        Status    string `json:"status"`
+        CreatedAt string `json:"created_at"`
+    } `json:"jobs"`
+    NextCursor string `json:"next_cursor"`
+}
+
+func submitAsUser(t *testing.T, userID, projectID string) string {
+    body := `{"project_id": "` + projectID + `", "payload": "sleep:10ms", "next_fire_at": "2099-01-01T00:00:00Z"}`
+    req, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/submit", strings.NewReader(body))
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("X-User-ID", userID)
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatalf("Failed to submit job: %v", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusCreated {
+        t.Fatalf("Submit failed with status %d", resp.StatusCode)
+    }
+    var result map[string]string
+    json.NewDecoder(resp.Body).Decode(&result)
+    return result["job_id"]
+}
+
+func listJobs(t *testing.T, userID string, q url.Values) (int, jobsPage) {
+    req, _ := http.NewRequest(http.MethodGet, "http://localhost:8080/jobs?"+q.Encode(), nil)
+    req.Header.Set("X-User-ID", userID)
+    resp, err := http.DefaultClient.Do(req)
+    if err != nil {
+        t.Fatalf("GET /jobs: %v", err)
+    }
+    defer resp.Body.Close()
+    var page jobsPage
+    json.NewDecoder(resp.Body).Decode(&page)
+    return resp.StatusCode, page
+}
+
+func TestListJobsPaginates(t *testing.T) {
+    userID := "listing-" + uuid.New().String()
+    submitted := map[string]bool{}
+    for i := 0; i < 5; i++ {
+        submitted[submitAsUser(t, userID, "listing-test")] = true
+    }
+
+    seen := map[string]bool{}
+    var prevCreated string
+    q := url.Values{"limit": {"2"}}
+    for pages := 0; ; pages++ {
+        if pages > 5 {
+            t.Fatal("pagination did not terminate")
+        }
+        code, page := listJobs(t, userID, q)
+        if code != http.StatusOK {
+            t.Fatalf("expected 200, got %d", code)
+        }
+        if len(page.Jobs) > 2 {
+            t.Fatalf("page has %d jobs, limit is 2", len(page.Jobs))
+        }
+        for _, j := range page.Jobs {
+            if seen[j.JobID] {
+                t.Errorf("job %s returned twice", j.JobID)
+            }
+            seen[j.JobID] = true
+            if prevCreated != "" && j.CreatedAt > prevCreated {
+                t.Errorf("jobs not newest first: %s after %s", j.CreatedAt, prevCreated)
+            }
+            prevCreated = j.CreatedAt
+        }
+        if page.NextCursor == "" {
+            break
+        }
+        q.Set("cursor", page.NextCursor)
+    }
+
+    if len(seen) != len(submitted) {
+        t.Errorf("listed %d jobs, submitted %d", len(seen), len(submitted))
+    }
+}
+
+func TestListJobsFilters(t *testing.T) {
+    userID := "listing-" + uuid.New().String()
+    first := submitAsUser(t, userID, "alpha")
+    time.Sleep(1100 * time.Millisecond) // created_at filters have second precision in RFC3339
+    cutoff := time.Now().UTC().Truncate(time.Second)
+    time.Sleep(100 * time.Millisecond)
+    second := submitAsUser(t, userID, "beta")
+    third := submitAsUser(t, userID, "alpha")
+    pause, _ := http.NewRequest(http.MethodPost, "http://localhost:8080/job/"+third+"/pause", nil)
+    pause.Header.Set("X-User-ID", userID)
+    if resp, err := http.DefaultClient.Do(pause); err != nil || resp.StatusCode != http.StatusOK {
+        t.Fatalf("pause failed: %v %v", resp, err)
+    }
+
+    ids := func(q url.Values) []string {
+        code, page := listJobs(t, userID, q)
+        if code != http.StatusOK {
+            t.Fatalf("%v: expected 200, got %d", q, code)
+        }
+        var out []string
+        for _, j := range page.Jobs {
+            out = append(out, j.JobID)
+        }
+        // Filtered pages can come back short; follow the cursor to the end
+        for page.NextCursor != "" {
+            q.Set("cursor", page.NextCursor)
+            _, page = listJobs(t, userID, q)
+            for _, j := range page.Jobs {
+                out = append(out, j.JobID)
+            }
+        }
+        return out
+    }
+
+    if got := ids(url.Values{"project_id": {"alpha"}, "order": {"asc"}}); len(got) != 2 || got[0] != first || got[1] != third {
+        t.Errorf("project_id=alpha ascending: got %v, want [%s %s]", got, first, third)
+    }
+    if got := ids(url.Values{"status": {"paused"}}); len(got) != 1 || got[0] != third {
+        t.Errorf("status=paused: got %v, want [%s]", got, third)
+    }
+    after := ids(url.Values{"created_after": {cutoff.Format(time.RFC3339)}})
+    if len(after) != 2 || (after[0] != third && after[1] != third) || (after[0] != second && after[1] != second) {
+        t.Errorf("created_after: got %v, want %s and %s", after, second, third)
+    }
+    if got := ids(url.Values{"created_before": {cutoff.Format(time.RFC3339)}}); len(got) != 1 || got[0] != first {
+        t.Errorf("created_before: got %v, want [%s]", got, first)
+    }
+}
+
+func TestListJobsRejectsMismatchedCursor(t *testing.T) {
+    userID := "listing-" + uuid.New().String()
+    for i := 0; i < 3; i++ {
+        submitAsUser(t, userID, "listing-test")
+    }
+    _, page := listJobs(t, userID, url.Values{"limit": {"1"}})
+    if page.NextCursor == "" {
+        t.Fatal("expected a next_cursor")
+    }
+    code, _ := listJobs(t, userID, url.Values{"limit": {"1"}, "status": {"PENDING"}, "cursor": {page.NextCursor}})
+    if code != http.StatusBadRequest {
+        t.Errorf("cursor reused with different filters: expected 400, got %d", code)
+    }
+    if code, _ := listJobs(t, userID, url.Values{"limit": {"10000"}}); code != http.StatusBadRequest {
+        t.Errorf("limit above maximum: expected 400, got %d", code)
+    }
+}
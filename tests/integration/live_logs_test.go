@@ -0,0 +1,87 @@
+package integration
+
+import (
+    "bufio"
+    "encoding/json"
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+)
+
+type logEvent struct {
+    Event string
+    Data  map[string]string
+}
+
+func TestFollowLiveRunLogs(t *testing.T) {
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id": "live-logs-test",
+        "job_type":   "cmd",
+        "payload":    `for i in 1 2 3; do echo "out $i"; echo "err $i" >&2; sleep 1; done`,
+    })
+
+    // The run is listed as RUNNING before it is recorded in job_runs
+    var runID string
+    deadline := time.Now().Add(15 * time.Second)
+    for runID == "" && time.Now().Before(deadline) {
+        var page runsPage
+        if getJSON(t, "/job/"+jobID+"/runs", &page) == http.StatusOK && len(page.Runs) > 0 && page.Runs[0].Status == "RUNNING" {
+            runID = page.Runs[0].RunID
+            break
+        }
+        time.Sleep(200 * time.Millisecond)
+    }
+    if runID == "" {
+        t.Fatal("run never showed up as RUNNING")
+    }
+
+    resp, err := http.Get("http://localhost:8080/job/" + jobID + "/runs/" + runID + "/logs?follow=true")
+    if err != nil {
+        t.Fatalf("GET logs: %v", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+        t.Fatalf("GET logs: status %d, content type %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+    }
+
+    var events []logEvent
+    var cur logEvent
+    scanner := bufio.NewScanner(resp.Body)
+    for scanner.Scan() {
+        line := scanner.Text()
+        switch {
+        case strings.HasPrefix(line, "event: "):
+            cur.Event = strings.TrimPrefix(line, "event: ")
+        case strings.HasPrefix(line, "data: "):
+            json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &cur.Data)
+        case line == "" && cur.Event != "":
+            events = append(events, cur)
+            cur = logEvent{}
+        }
+    }
+
+    var stdout, stderr []string
+    for _, e := range events {
+        if e.Event != "stdout" && e.Event != "stderr" {
+            continue
+        }
+        if _, err := time.Parse(time.RFC3339Nano, e.Data["ts"]); err != nil {
+            t.Errorf("line without a timestamp: %v", e.Data)
+        }
+        if e.Event == "stdout" {
+            stdout = append(stdout, e.Data["line"])
+        } else {
+            stderr = append(stderr, e.Data["line"])
+        }
+    }
+    if got := strings.Join(stdout, "|"); got != "out 1|out 2|out 3" {
+        t.Errorf("stdout %q", got)
+    }
+    if got := strings.Join(stderr, "|"); got != "err 1|err 2|err 3" {
+        t.Errorf("stderr %q", got)
+    }
+    if len(events) == 0 || events[len(events)-1].Event != "end" || events[len(events)-1].Data["status"] != "COMPLETED" {
+        t.Errorf("stream did not end with a COMPLETED end event: %v", events)
+    }
+}
@@ -24,7 +24,7 @@ func TestLoad(t *testing.T) {
         go func(workerID int) {
             defer wg.Done()
             for j := 0; j < jobsPerSubmitter; j++ {
-                payload := fmt.Sprintf("load-test-w%d-%d", workerID, j)
+                payload := fmt.Sprintf("echo:load-test-w%d-%d", workerID, j)
                 jobID := submitJob(t, "load-test", payload, "", "")
                 jobIDs <- jobID
             }
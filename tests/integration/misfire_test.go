@@ -0,0 +1,84 @@
+package integration
+
+import (
+    "net/http"
+    "testing"
+    "time"
+)
+
+func TestRecurringRunsStayOnSchedule(t *testing.T) {
+    // Every 2s, but each run takes 3s: every other occurrence is missed
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id":     "misfire-test",
+        "payload":        "sleep:3s",
+        "cron_schedule":  "*/2 * * * * *",
+        "next_fire_at":   time.Now().Add(2 * time.Second).Truncate(time.Second).Format(time.RFC3339),
+        "misfire_policy": "fire_once",
+    })
+
+    time.Sleep(15 * time.Second)
+    postJobAction(t, jobID, "cancel")
+
+    iter := scyllaClient.Session.Query(`SELECT scheduled_for FROM job_runs WHERE job_id = ?`, jobID).Iter()
+    var scheduledFor time.Time
+    runs := 0
+    for iter.Scan(&scheduledFor) {
+        runs++
+        // Anchored on the previous occurrence, not on when the run ended
+        if scheduledFor.Second()%2 != 0 || scheduledFor.Nanosecond() != 0 {
+            t.Errorf("Run scheduled for %v drifted off the */2 grid", scheduledFor)
+        }
+    }
+    if err := iter.Close(); err != nil {
+        t.Fatalf("Failed to read runs: %v", err)
+    }
+    if runs < 2 {
+        t.Fatalf("Expected at least 2 runs, got %d", runs)
+    }
+}
+
+func TestSkipPolicyDropsMissedOccurrences(t *testing.T) {
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id":     "misfire-test",
+        "payload":        "sleep:3s",
+        "cron_schedule":  "@every 1s",
+        "misfire_policy": "skip",
+    })
+    defer postJobAction(t, jobID, "cancel")
+
+    // Runs come back newest first
+    type run struct{ scheduledFor, completedAt time.Time }
+    var runs []run
+    deadline := time.Now().Add(30 * time.Second)
+    for len(runs) < 2 && time.Now().Before(deadline) {
+        time.Sleep(time.Second)
+        runs = runs[:0]
+        iter := scyllaClient.Session.Query(`SELECT scheduled_for, completed_at FROM job_runs WHERE job_id = ?`, jobID).Iter()
+        var r run
+        for iter.Scan(&r.scheduledFor, &r.completedAt) {
+            runs = append(runs, r)
+        }
+        iter.Close()
+    }
+    if len(runs) < 2 {
+        t.Fatalf("Expected 2 runs, got %d", len(runs))
+    }
+
+    // The 3s run missed ~2 occurrences; with skip the next run is the first
+    // occurrence after it finished, not one of the missed ones
+    first, second := runs[len(runs)-1], runs[len(runs)-2]
+    if second.scheduledFor.Before(first.completedAt.Add(-100 * time.Millisecond)) {
+        t.Errorf("skip policy ran a missed occurrence: scheduled %v, previous run finished %v", second.scheduledFor, first.completedAt)
+    }
+}
+
+func TestInvalidMisfirePolicyRejected(t *testing.T) {
+    for _, req := range []map[string]interface{}{
+        {"project_id": "misfire-test", "payload": "echo:ok", "cron_schedule": "@every 1m", "misfire_policy": "sometimes"},
+        {"project_id": "misfire-test", "payload": "echo:ok", "cron_schedule": "@every 1m", "max_catchup": -1},
+    } {
+        if code, body := submitJobStatus(t, req); code != http.StatusBadRequest {
+            t.Errorf("Expected 400 for %v, got %d: %s", req, code, body)
+        }
+    }
+}
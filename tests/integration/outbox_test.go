@@ -0,0 +1,36 @@
+package integration
+
+import (
+    "testing"
+    "time"
+
+    "github.com/gocql/gocql"
+
+    "distributed_job_scheduler/pkg/outbox"
+)
+
+func TestSubmitDeletesOutboxEntry(t *testing.T) {
+    jobID := submitJob(t, "outbox-test", "echo:ok", "", "")
+
+    // The inline publish deletes the entry right after the 201
+    bucket := outbox.Bucket(jobID)
+    query := `SELECT status FROM submission_outbox WHERE bucket = ? AND job_id = ? ALLOW FILTERING`
+
+    deadline := time.Now().Add(10 * time.Second)
+    for {
+        var status string
+        err := scyllaClient.Session.Query(query, bucket, jobID).Scan(&status)
+        if err == gocql.ErrNotFound {
+            break
+        }
+        if err != nil {
+            t.Fatalf("Failed to read outbox entry for job %s: %v", jobID, err)
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("Outbox entry for job %s still %s", jobID, status)
+        }
+        time.Sleep(500 * time.Millisecond)
+    }
+
+    waitForJobCompletion(t, jobID, 30*time.Second)
+}
@@ -9,9 +9,9 @@ func TestRecurringJobExecution(t *testing.T) {
     // 1. Submit Recurring Job (@every 2s)
     // We use @every 2s to ensure it runs quickly for the test
     schedule := "@every 2s"
-    payload := "test-recurring-" + time.Now().Format(time.RFC3339)
+    payload := "echo:test-recurring-" + time.Now().Format(time.RFC3339)
     
-    // Immediate first run
+    // No next_fire_at: first run at the first occurrence (~2s)
     jobID := submitJob(t, "integration-test", payload, schedule, "")
 
     t.Logf("Submitted recurring job: %s (Schedule: %s)", jobID, schedule)
@@ -0,0 +1,56 @@
+package integration
+
+import (
+    "testing"
+    "time"
+)
+
+func TestFailedJobRetriesUntilExhausted(t *testing.T) {
+    // Fails every time: expect 1 initial run + 2 retries, then terminal FAILED
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id":  "retry-test",
+        "payload":     "cmd:exit 3",
+        "max_retries": 2,
+    })
+    t.Logf("Submitted failing job: %s", jobID)
+
+    // Default backoff is 5s then 10s (+/- jitter)
+    deadline := time.Now().Add(60 * time.Second)
+    for time.Now().Before(deadline) {
+        if GetJobStatus(t, jobID) == "FAILED" {
+            break
+        }
+        time.Sleep(1 * time.Second)
+    }
+    if status := GetJobStatus(t, jobID); status != "FAILED" {
+        t.Fatalf("Expected terminal status FAILED, got %s", status)
+    }
+
+    iter := scyllaClient.Session.Query(`SELECT attempt, status FROM job_runs WHERE job_id = ?`, jobID).Iter()
+    attempts := map[int]bool{}
+    var attempt int
+    var status string
+    for iter.Scan(&attempt, &status) {
+        if status != "FAILED" {
+            t.Errorf("Attempt %d has status %s, want FAILED", attempt, status)
+        }
+        attempts[attempt] = true
+    }
+    if err := iter.Close(); err != nil {
+        t.Fatalf("Failed to read job_runs: %v", err)
+    }
+
+    for want := 1; want <= 3; want++ {
+        if !attempts[want] {
+            t.Errorf("Missing job_runs row for attempt %d (got %v)", want, attempts)
+        }
+    }
+
+    var retryCount int
+    if err := scyllaClient.Session.Query(`SELECT retry_count FROM jobs WHERE job_id = ?`, jobID).Scan(&retryCount); err != nil {
+        t.Fatalf("Failed to read retry_count: %v", err)
+    }
+    if retryCount != 2 {
+        t.Errorf("Expected retry_count 2, got %d", retryCount)
+    }
+}
@@ -0,0 +1,156 @@
+package integration
+
+import (
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/url"
+    "strings"
+    "testing"
+    "time"
+)
+
+type runRecord struct {
+    RunID           string  `json:"run_id"`
+    Status          string  `json:"status"`
+    Attempt         int     `json:"attempt"`
+    WorkerID        string  `json:"worker_id"`
+    ErrorMessage    string  `json:"error_message"`
+    ExitCode        *int    `json:"exit_code"`
+    TermSignal      string  `json:"term_signal"`
+    Output          *string `json:"output"`
+    OutputRef       string  `json:"output_ref"`
+    OutputBytes     int64   `json:"output_bytes"`
+    OutputTruncated bool    `json:"output_truncated"`
+}
+
+type runsPage struct {
+    Runs       []runRecord `json:"runs"`
+    NextCursor string      `json:"next_cursor"`
+}
+
+func getJSON(t *testing.T, path string, v interface{}) int {
+    t.Helper()
+    resp, err := http.Get("http://localhost:8080" + path)
+    if err != nil {
+        t.Fatalf("GET %s: %v", path, err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode == http.StatusOK {
+        if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+            t.Fatalf("GET %s: decode: %v", path, err)
+        }
+    }
+    return resp.StatusCode
+}
+
+// fetchRunOutput downloads the complete output of a run through the API.
+func fetchRunOutput(t *testing.T, jobID, runID string) string {
+    t.Helper()
+    resp, err := http.Get("http://localhost:8080/job/" + jobID + "/runs/" + runID + "/output")
+    if err != nil {
+        t.Fatalf("GET run output: %v", err)
+    }
+    defer resp.Body.Close()
+    body, _ := io.ReadAll(resp.Body)
+    if resp.StatusCode != http.StatusOK {
+        t.Fatalf("GET run output: status %d: %s", resp.StatusCode, body)
+    }
+    return string(body)
+}
+
+func TestRunHistoryAPI(t *testing.T) {
+    // Fails every time: 1 run + 2 retries, each a separate job_runs row
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id":  "run-history-test",
+        "payload":     "cmd:echo attempt-output; exit 3",
+        "max_retries": 2,
+    })
+    deadline := time.Now().Add(60 * time.Second)
+    for time.Now().Before(deadline) && GetJobStatus(t, jobID) != "FAILED" {
+        time.Sleep(time.Second)
+    }
+
+    // Page through one run at a time; newest (highest attempt) first
+    var runs []runRecord
+    q := url.Values{"limit": {"1"}}
+    for pages := 0; pages < 10; pages++ {
+        var page runsPage
+        if code := getJSON(t, "/job/"+jobID+"/runs?"+q.Encode(), &page); code != http.StatusOK {
+            t.Fatalf("list runs: status %d", code)
+        }
+        runs = append(runs, page.Runs...)
+        if page.NextCursor == "" {
+            break
+        }
+        q.Set("cursor", page.NextCursor)
+    }
+    if len(runs) != 3 {
+        t.Fatalf("expected 3 runs, got %d: %+v", len(runs), runs)
+    }
+    for i, run := range runs {
+        if run.Attempt != 3-i {
+            t.Errorf("run %d has attempt %d, want %d (newest first)", i, run.Attempt, 3-i)
+        }
+        if run.Output != nil {
+            t.Errorf("listing included output for run %s", run.RunID)
+        }
+        if run.Status != "FAILED" || run.WorkerID == "" {
+            t.Errorf("unexpected run %+v", run)
+        }
+    }
+
+    var run runRecord
+    if code := getJSON(t, "/job/"+jobID+"/runs/"+runs[0].RunID, &run); code != http.StatusOK {
+        t.Fatalf("get run: status %d", code)
+    }
+    if run.Output == nil || *run.Output != "attempt-output\n" {
+        t.Errorf("unexpected run output %v", run.Output)
+    }
+    if run.ErrorMessage == "" {
+        t.Error("failed run has no error_message")
+    }
+    if run.ExitCode == nil || *run.ExitCode != 3 || run.TermSignal != "" {
+        t.Errorf("expected exit_code 3 and no term_signal, got %v / %q", run.ExitCode, run.TermSignal)
+    }
+
+    if code := getJSON(t, "/job/"+jobID+"/runs/00000000-0000-1000-8000-000000000000", &run); code != http.StatusNotFound {
+        t.Errorf("unknown run: expected 404, got %d", code)
+    }
+}
+
+func TestRunHistoryRequiresOwner(t *testing.T) {
+    jobID := submitAsUser(t, "run-history-owner", "run-history-test")
+    if code := getJSON(t, "/job/"+jobID+"/runs", &runsPage{}); code != http.StatusForbidden {
+        t.Errorf("expected 403 without X-User-ID, got %d", code)
+    }
+}
+
+func TestLargeOutputOffloadedToS3(t *testing.T) {
+    const size = 50000
+    jobID := submitJob(t, "output-offload-test", "cmd:head -c 50000 /dev/zero | tr '\\0' 'x'", "", "")
+    waitForJobCompletion(t, jobID, 30*time.Second)
+
+    var page runsPage
+    if code := getJSON(t, "/job/"+jobID+"/runs", &page); code != http.StatusOK || len(page.Runs) != 1 {
+        t.Fatalf("list runs: status %d, %d runs", code, len(page.Runs))
+    }
+    runID := page.Runs[0].RunID
+
+    var run runRecord
+    getJSON(t, "/job/"+jobID+"/runs/"+runID, &run)
+    if run.OutputBytes != size || !run.OutputTruncated {
+        t.Errorf("expected a truncated %d-byte output, got %d bytes (truncated=%v)", size, run.OutputBytes, run.OutputTruncated)
+    }
+    if want := "s3:outputs/" + jobID + "/" + runID; run.OutputRef != want {
+        t.Errorf("output_ref %q, want %q", run.OutputRef, want)
+    }
+    if run.Output == nil || len(*run.Output) != 1024 {
+        t.Errorf("expected a 1KB preview in job_runs")
+    }
+
+    full := fetchRunOutput(t, jobID, runID)
+    if len(full) != size || strings.Trim(full, "x") != "" {
+        t.Errorf("streamed output has %d bytes, want %d x's", len(full), size)
+    }
+}
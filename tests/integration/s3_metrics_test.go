@@ -13,19 +13,20 @@ func TestS3OffloadingAndMetrics(t *testing.T) {
 	largePayload := "s3-offload-test:" + strings.Repeat("A", 2048) // > 2KB
 	projectID := "s3-metrics-test"
 	
-	jobID := submitJob(t, projectID, largePayload, "", "")
+	jobID := submitJob(t, projectID, "echo:"+largePayload, "", "")
 	t.Logf("Submitted job %s with large payload", jobID)
 
 	// 2. Wait for completion
 	waitForJobCompletion(t, jobID, 30*time.Second)
 	
-	// 3. Verify Output contains the FULL payload (meaning Worker downloaded it)
-	// Query job_runs table
-	var output string
-	query := `SELECT output FROM job_runs WHERE job_id = ? ALLOW FILTERING`
-	if err := scyllaClient.Session.Query(query, jobID).Scan(&output); err != nil {
-		t.Fatalf("Failed to fetch job run output: %v", err)
+	// 3. Verify Output contains the FULL payload (meaning Worker downloaded it).
+	// The output is over 1KB too, so it comes back from S3 through the run API.
+	var runID string
+	query := `SELECT run_id FROM job_runs WHERE job_id = ? LIMIT 1`
+	if err := scyllaClient.Session.Query(query, jobID).Scan(&runID); err != nil {
+		t.Fatalf("Failed to fetch job run: %v", err)
 	}
+	output := fetchRunOutput(t, jobID, runID)
 
 	if !strings.Contains(output, largePayload) {
 		t.Errorf("Job output does not contain original payload. Got length: %d", len(output))
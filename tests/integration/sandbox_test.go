@@ -0,0 +1,34 @@
+package integration
+
+import (
+    "net/http"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestCommandRunsSandboxed(t *testing.T) {
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id": "sandbox-test",
+        "job_type":   "cmd",
+        "payload":    `id -u; pwd; echo "aws=$AWS_ACCESS_KEY_ID"; ulimit -n`,
+    })
+    waitForJobCompletion(t, jobID, 20*time.Second)
+
+    var page runsPage
+    if code := getJSON(t, "/job/"+jobID+"/runs", &page); code != http.StatusOK || len(page.Runs) == 0 {
+        t.Fatalf("list runs: status %d", code)
+    }
+    var run runRecord
+    getJSON(t, "/job/"+jobID+"/runs/"+page.Runs[0].RunID, &run)
+    if run.Output == nil {
+        t.Fatal("run has no output")
+    }
+
+    // Defaults: nobody, a scratch directory per run, no worker environment, 1024 open files
+    lines := strings.Split(strings.TrimSpace(*run.Output), "\n")
+    if len(lines) != 4 || lines[0] != "65534" || !strings.HasPrefix(lines[1], "/tmp/job-runs/run-") ||
+        lines[2] != "aws=" || lines[3] != "1024" {
+        t.Errorf("unexpected sandbox output %q", *run.Output)
+    }
+}
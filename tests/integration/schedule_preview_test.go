@@ -0,0 +1,150 @@
+package integration
+
+import (
+    "encoding/json"
+    "io"
+    "net/http"
+    "net/url"
+    "testing"
+    "time"
+)
+
+type schedulePreview struct {
+    Timezone  string      `json:"timezone"`
+    RetryAt   *time.Time  `json:"retry_at"`
+    FireTimes []time.Time `json:"fire_times"`
+    Error     struct {
+        Code  string `json:"code"`
+        Field string `json:"field"`
+    } `json:"error"`
+}
+
+func getPreview(t *testing.T, path string) (int, schedulePreview) {
+    t.Helper()
+    resp, err := http.Get("http://localhost:8080" + path)
+    if err != nil {
+        t.Fatalf("GET %s: %v", path, err)
+    }
+    defer resp.Body.Close()
+    body, _ := io.ReadAll(resp.Body)
+
+    var p schedulePreview
+    if err := json.Unmarshal(body, &p); err != nil {
+        t.Fatalf("GET %s: body is not JSON (status %d): %s", path, resp.StatusCode, body)
+    }
+    return resp.StatusCode, p
+}
+
+func TestSchedulePreviewListsFireTimes(t *testing.T) {
+    q := url.Values{"cron": {"0 0 9 * * MON-FRI"}, "tz": {"America/New_York"}, "count": {"5"}}
+    code, p := getPreview(t, "/schedule/preview?"+q.Encode())
+    if code != http.StatusOK {
+        t.Fatalf("expected 200, got %d", code)
+    }
+    if p.Timezone != "America/New_York" || len(p.FireTimes) != 5 {
+        t.Fatalf("unexpected preview: %+v", p)
+    }
+
+    loc, err := time.LoadLocation("America/New_York")
+    if err != nil {
+        t.Skipf("tzdata unavailable on the test host: %v", err)
+    }
+    for i, ft := range p.FireTimes {
+        local := ft.In(loc)
+        if local.Hour() != 9 || local.Minute() != 0 || local.Weekday() == time.Saturday || local.Weekday() == time.Sunday {
+            t.Errorf("fire %d at %v is not a weekday 09:00 in New York", i, local)
+        }
+        if i > 0 && !ft.After(p.FireTimes[i-1]) {
+            t.Errorf("fire times out of order: %v then %v", p.FireTimes[i-1], ft)
+        }
+    }
+}
+
+func TestSchedulePreviewRejectsInvalidInput(t *testing.T) {
+    cases := []struct {
+        query url.Values
+        code  string
+        field string
+    }{
+        {url.Values{"cron": {"0 9 * * 1"}}, "invalid_cron_schedule", "cron"}, // five-field crontab
+        {url.Values{"cron": {"@daily"}, "tz": {"Nowhere/Special"}}, "invalid_timezone", "tz"},
+        {url.Values{"cron": {"@daily"}, "count": {"0"}}, "invalid_count", "count"},
+        {url.Values{}, "missing_cron", "cron"},
+    }
+    for _, c := range cases {
+        code, p := getPreview(t, "/schedule/preview?"+c.query.Encode())
+        if code != http.StatusBadRequest || p.Error.Code != c.code || p.Error.Field != c.field {
+            t.Errorf("%v: got %d %+v, want 400 %s/%s", c.query, code, p.Error, c.code, c.field)
+        }
+    }
+}
+
+func TestJobUpcomingStartsAtQueuedFire(t *testing.T) {
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id":    "preview-test",
+        "payload":       "echo:ok",
+        "cron_schedule": "0 0 12 * * *",
+        "timezone":      "Asia/Tokyo",
+        "next_fire_at":  time.Now().Add(time.Hour).UTC().Format(time.RFC3339),
+    })
+
+    code, p := getPreview(t, "/job/"+jobID+"/upcoming?count=3")
+    if code != http.StatusOK || len(p.FireTimes) != 3 {
+        t.Fatalf("expected 3 fire times, got %d %+v", code, p)
+    }
+    job := getJobDetails(t, jobID)
+    if !p.FireTimes[0].Equal(job.NextFireAt) {
+        t.Errorf("first upcoming %v != next_fire_at %v", p.FireTimes[0], job.NextFireAt)
+    }
+    if d := p.FireTimes[2].Sub(p.FireTimes[1]); d != 24*time.Hour {
+        t.Errorf("daily occurrences %v apart", d)
+    }
+    if p.FireTimes[1].UTC().Hour() != 3 { // 12:00 JST
+        t.Errorf("occurrence %v is not 12:00 in Tokyo", p.FireTimes[1])
+    }
+
+    postJobAction(t, jobID, "cancel")
+    if _, p := getPreview(t, "/job/"+jobID+"/upcoming"); len(p.FireTimes) != 0 {
+        t.Errorf("cancelled job still has upcoming fires: %v", p.FireTimes)
+    }
+}
+
+func TestJobUpcomingOfRetryingJob(t *testing.T) {
+    // Fails every run; the 5s retry backoff outlasts the 2s schedule interval
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id":    "preview-test",
+        "payload":       "cmd:exit 1",
+        "cron_schedule": "*/2 * * * * *",
+        "max_retries":   3,
+    })
+
+    deadline := time.Now().Add(20 * time.Second)
+    for GetJobStatus(t, jobID) != "RETRYING" {
+        if time.Now().After(deadline) {
+            t.Fatalf("Job %s never reached RETRYING", jobID)
+        }
+        time.Sleep(200 * time.Millisecond)
+    }
+    defer postJobAction(t, jobID, "cancel")
+
+    var nextFireAt, occurrenceAt time.Time
+    query := `SELECT next_fire_at, occurrence_at FROM jobs WHERE job_id = ?`
+    if err := scyllaClient.Session.Query(query, jobID).Scan(&nextFireAt, &occurrenceAt); err != nil {
+        t.Fatalf("Failed to read job: %v", err)
+    }
+
+    code, p := getPreview(t, "/job/"+jobID+"/upcoming?count=3")
+    if code != http.StatusOK || p.RetryAt == nil || len(p.FireTimes) != 3 {
+        t.Fatalf("expected a retry and 3 fire times, got %d %+v", code, p)
+    }
+    if !p.RetryAt.Equal(nextFireAt) {
+        t.Errorf("retry_at %v != next_fire_at %v", *p.RetryAt, nextFireAt)
+    }
+    // The schedule continues from the occurrence being retried, not the retry
+    if d := p.FireTimes[0].Sub(occurrenceAt); d <= 0 || d > 2*time.Second {
+        t.Errorf("first upcoming %v does not follow occurrence %v", p.FireTimes[0], occurrenceAt)
+    }
+    if !p.FireTimes[0].Before(*p.RetryAt) {
+        t.Errorf("first upcoming %v was computed from the retry at %v", p.FireTimes[0], *p.RetryAt)
+    }
+}
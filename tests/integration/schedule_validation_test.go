@@ -0,0 +1,102 @@
+package integration
+
+import (
+    "encoding/json"
+    "net/http"
+    "testing"
+    "time"
+)
+
+func TestInvalidCronRejectedWithFieldError(t *testing.T) {
+    for _, spec := range []string{"every minute", "* * * *", "61 * * * * *", "@every banana"} {
+        code, body := submitJobStatus(t, map[string]interface{}{
+            "project_id":    "validation-test",
+            "payload":       "echo:ok",
+            "cron_schedule": spec,
+        })
+        if code != http.StatusBadRequest {
+            t.Errorf("%q: expected 400, got %d: %s", spec, code, body)
+            continue
+        }
+
+        var resp struct {
+            Error struct {
+                Code    string `json:"code"`
+                Field   string `json:"field"`
+                Message string `json:"message"`
+            } `json:"error"`
+        }
+        if err := json.Unmarshal([]byte(body), &resp); err != nil {
+            t.Fatalf("%q: error body is not JSON: %s", spec, body)
+        }
+        if resp.Error.Code != "invalid_cron_schedule" || resp.Error.Field != "cron_schedule" || resp.Error.Message == "" {
+            t.Errorf("%q: unexpected error body %s", spec, body)
+        }
+    }
+}
+
+func TestFirstFireComputedFromCron(t *testing.T) {
+    before := time.Now()
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id":    "validation-test",
+        "payload":       "echo:ok",
+        "cron_schedule": "0 0 3 * * *", // daily at 03:00 UTC
+    })
+    defer postJobAction(t, jobID, "cancel")
+
+    job := getJobDetails(t, jobID)
+    next := job.NextFireAt.UTC() // the service container runs in UTC
+    if !next.After(before) || next.Sub(before) > 24*time.Hour {
+        t.Fatalf("next_fire_at %v is not the next 03:00 after %v", next, before)
+    }
+    if next.Hour() != 3 || next.Minute() != 0 || next.Second() != 0 {
+        t.Errorf("next_fire_at %v is not at 03:00:00", next)
+    }
+}
+
+func TestInvalidTimezoneRejected(t *testing.T) {
+    for _, tz := range []string{"Mars/Olympus_Mons", "Local", "GMT+25"} {
+        code, body := submitJobStatus(t, map[string]interface{}{
+            "project_id":    "validation-test",
+            "payload":       "echo:ok",
+            "cron_schedule": "0 0 9 * * *",
+            "timezone":      tz,
+        })
+        if code != http.StatusBadRequest {
+            t.Errorf("%q: expected 400, got %d: %s", tz, code, body)
+            continue
+        }
+        var resp struct {
+            Error struct {
+                Code  string `json:"code"`
+                Field string `json:"field"`
+            } `json:"error"`
+        }
+        json.Unmarshal([]byte(body), &resp)
+        if resp.Error.Code != "invalid_timezone" || resp.Error.Field != "timezone" {
+            t.Errorf("%q: unexpected error body %s", tz, body)
+        }
+    }
+}
+
+func TestFirstFireEvaluatedInTimezone(t *testing.T) {
+    loc, err := time.LoadLocation("Asia/Kolkata") // UTC+05:30, no DST
+    if err != nil {
+        t.Skipf("tzdata unavailable on the test host: %v", err)
+    }
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id":    "validation-test",
+        "payload":       "echo:ok",
+        "cron_schedule": "0 0 9 * * *",
+        "timezone":      "Asia/Kolkata",
+    })
+    defer postJobAction(t, jobID, "cancel")
+
+    next := getJobDetails(t, jobID).NextFireAt.In(loc)
+    if next.Hour() != 9 || next.Minute() != 0 {
+        t.Errorf("next_fire_at %v is not 09:00 in Asia/Kolkata", next)
+    }
+    if utc := next.UTC(); utc.Hour() != 3 || utc.Minute() != 30 {
+        t.Errorf("next_fire_at %v should be 03:30 UTC", utc)
+    }
+}
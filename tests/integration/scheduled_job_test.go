@@ -9,7 +9,7 @@ func TestScheduledJobExecution(t *testing.T) {
     // 1. Submit Job for 5 seconds later
     delay := 5 * time.Second
     fireAt := time.Now().Add(delay)
-    payload := "test-scheduled-" + fireAt.Format(time.RFC3339)
+    payload := "echo:test-scheduled-" + fireAt.Format(time.RFC3339)
     
     // Format required by Ingestion Service: RFC3339
     jobID := submitJob(t, "integration-test", payload, "", fireAt.Format(time.RFC3339))
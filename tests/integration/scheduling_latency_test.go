@@ -0,0 +1,30 @@
+package integration
+
+import (
+    "testing"
+    "time"
+)
+
+func TestRunRecordsSchedulingTimeline(t *testing.T) {
+    fireAt := time.Now().Add(3 * time.Second).UTC().Truncate(time.Second)
+    jobID := submitJob(t, "latency-test", "echo:ok", "", fireAt.Format(time.RFC3339))
+
+    waitForJobCompletion(t, jobID, 30*time.Second)
+
+    var scheduledFor, dispatchedAt, startedAt time.Time
+    query := `SELECT scheduled_for, dispatched_at, started_at FROM job_runs WHERE job_id = ? LIMIT 1`
+    if err := scyllaClient.Session.Query(query, jobID).Scan(&scheduledFor, &dispatchedAt, &startedAt); err != nil {
+        t.Fatalf("Failed to read run timeline: %v", err)
+    }
+
+    if !scheduledFor.Equal(fireAt) {
+        t.Errorf("scheduled_for = %v, want the submitted next_fire_at %v", scheduledFor, fireAt)
+    }
+    if dispatchedAt.Before(scheduledFor) {
+        t.Errorf("dispatched_at %v is before scheduled_for %v", dispatchedAt, scheduledFor)
+    }
+    if startedAt.Before(dispatchedAt) {
+        t.Errorf("started_at %v is before dispatched_at %v", startedAt, dispatchedAt)
+    }
+    t.Logf("Schedule→dispatch %v, dispatch→start %v", dispatchedAt.Sub(scheduledFor), startedAt.Sub(dispatchedAt))
+}
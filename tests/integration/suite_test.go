@@ -87,6 +87,42 @@ func submitJob(t *testing.T, projectID, payload, schedule, nextFireAt string) st
     return result["job_id"]
 }
 
+// submitJobRequest posts an arbitrary request body, for tests that need
+// fields the positional submitJob helper doesn't cover.
+func submitJobRequest(t *testing.T, req map[string]interface{}) string {
+    body, _ := json.Marshal(req)
+    resp, err := http.Post("http://localhost:8080/submit", "application/json", strings.NewReader(string(body)))
+    if err != nil {
+        t.Fatalf("Failed to submit job: %v", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusCreated {
+        bodyBytes, _ := io.ReadAll(resp.Body)
+        t.Fatalf("Submit failed (Status %d): %s", resp.StatusCode, string(bodyBytes))
+    }
+
+    var result map[string]string
+    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+        t.Fatalf("Failed to decode response: %v", err)
+    }
+    return result["job_id"]
+}
+
+// submitJobStatus posts req to /submit and returns the status code and raw body,
+// for tests that expect the submission to be rejected.
+func submitJobStatus(t *testing.T, req map[string]interface{}) (int, string) {
+    body, _ := json.Marshal(req)
+    resp, err := http.Post("http://localhost:8080/submit", "application/json", strings.NewReader(string(body)))
+    if err != nil {
+        t.Fatalf("Failed to submit job: %v", err)
+    }
+    defer resp.Body.Close()
+
+    bodyBytes, _ := io.ReadAll(resp.Body)
+    return resp.StatusCode, string(bodyBytes)
+}
+
 func GetJobStatus(t *testing.T, jobID string) string {
     var status string
     // Check main jobs table
@@ -0,0 +1,45 @@
+package integration
+
+import (
+    "testing"
+    "time"
+)
+
+func TestCommandTimeoutKillsProcessGroup(t *testing.T) {
+    // The background sleep would keep the output pipe open if only `sh` were killed
+    jobID := submitJobRequest(t, map[string]interface{}{
+        "project_id":      "timeout-test",
+        "payload":         "cmd:sleep 60 & sleep 60",
+        "timeout_seconds": 2,
+    })
+    t.Logf("Submitted hanging job: %s", jobID)
+
+    deadline := time.Now().Add(20 * time.Second)
+    for time.Now().Before(deadline) {
+        if hasRunWithStatus(t, jobID, "TIMED_OUT") {
+            break
+        }
+        time.Sleep(500 * time.Millisecond)
+    }
+
+    var status, signal, termSignal string
+    var durationMs int64
+    var exitCode *int
+    query := `SELECT status, kill_signal, term_signal, exit_code, duration_ms FROM job_runs WHERE job_id = ?`
+    if err := scyllaClient.Session.Query(query, jobID).Scan(&status, &signal, &termSignal, &exitCode, &durationMs); err != nil {
+        t.Fatalf("Failed to fetch run: %v", err)
+    }
+
+    if status != "TIMED_OUT" {
+        t.Fatalf("Expected TIMED_OUT, got %s", status)
+    }
+    if signal != "SIGTERM" && signal != "SIGKILL" {
+        t.Errorf("Expected kill_signal SIGTERM or SIGKILL, got %q", signal)
+    }
+    if termSignal == "" || exitCode != nil {
+        t.Errorf("Expected the process to be terminated by a signal, got term_signal %q, exit_code %v", termSignal, exitCode)
+    }
+    if durationMs < 2000 || durationMs > 15000 {
+        t.Errorf("Expected run to stop shortly after the 2s timeout, took %dms", durationMs)
+    }
+}
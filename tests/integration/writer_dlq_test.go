@@ -0,0 +1,80 @@
+package integration
+
+import (
+    "encoding/json"
+    "fmt"
+    "testing"
+    "time"
+
+    "distributed_job_scheduler/pkg/infra"
+    "github.com/gocql/gocql"
+)
+
+func TestWriterDeadLettersBadEvents(t *testing.T) {
+    producer, err := infra.NewKafkaProducer("localhost:9092", "job-submissions")
+    if err != nil {
+        t.Fatalf("Failed to create producer: %v", err)
+    }
+    defer producer.Close()
+
+    // 1. A poison event followed by a valid one on the same key (same partition)
+    key := gocql.TimeUUID().String()
+    poison := []byte(`{"job_id": "not-a-uuid", "next_fire_at": "tomorrow"}`)
+    if err := producer.Publish(key, poison); err != nil {
+        t.Fatalf("Failed to publish poison event: %v", err)
+    }
+
+    jobID := gocql.TimeUUID().String()
+    fireAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+    valid, _ := json.Marshal(map[string]interface{}{
+        "job_id":       jobID,
+        "project_id":   "dlq-test",
+        "next_fire_at": fireAt.Format(time.RFC3339),
+        "shard_id":     7,
+        "payload":      "echo",
+    })
+    if err := producer.Publish(key, valid); err != nil {
+        t.Fatalf("Failed to publish valid event: %v", err)
+    }
+
+    // 2. The valid event isn't blocked behind the poison one
+    deadline := time.Now().Add(15 * time.Second)
+    for {
+        var found string
+        err := scyllaClient.Session.Query(`SELECT job_id FROM job_queue WHERE shard_id = ? AND next_fire_at = ? AND job_id = ?`, 7, fireAt, jobID).Scan(&found)
+        if err == nil {
+            break
+        }
+        if time.Now().After(deadline) {
+            t.Fatalf("Valid event was not written to job_queue: %v", err)
+        }
+        time.Sleep(500 * time.Millisecond)
+    }
+
+    // 3. The poison event is on the dead-letter topic with the reason attached
+    consumer, err := infra.NewKafkaConsumer("localhost:9092", fmt.Sprintf("dlq-test-%d", time.Now().UnixNano()), "job-submissions-dlq")
+    if err != nil {
+        t.Fatalf("Failed to create DLQ consumer: %v", err)
+    }
+    defer consumer.Close()
+
+    deadline = time.Now().Add(30 * time.Second)
+    for time.Now().Before(deadline) {
+        msg, err := consumer.ReadMessage(time.Second)
+        if err != nil || string(msg.Key) != key {
+            continue
+        }
+        var env map[string]interface{}
+        if err := json.Unmarshal(msg.Value, &env); err != nil {
+            t.Fatalf("Dead-letter message is not JSON: %v", err)
+        }
+        if env["value"] != string(poison) {
+            t.Fatalf("Dead-letter value = %v, want original event", env["value"])
+        }
+        if env["error"] == "" || env["topic"] != "job-submissions" {
+            t.Fatalf("Dead-letter envelope missing source or error: %v", env)
+        }
+        return
+    }
+    t.Fatal("Poison event never reached job-submissions-dlq")
+}